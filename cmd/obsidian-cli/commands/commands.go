@@ -2,13 +2,17 @@ package commands
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/chadmowery/obsidian-agent-tools/internal/gardener"
 	"github.com/chadmowery/obsidian-agent-tools/internal/llm"
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
 	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
 	"github.com/chadmowery/obsidian-agent-tools/internal/vectorstore"
 	"github.com/chadmowery/obsidian-agent-tools/internal/watcher"
@@ -17,6 +21,114 @@ import (
 type Dependencies struct {
 	VaultPath  string
 	JsonOutput bool
+	Version    string
+	Policy     *policy.Policy
+	// Command is the subcommand being run, recorded with journal entries
+	Command string
+	// DryRun shows the changes a command would make instead of making them
+	DryRun bool
+	// Git configures committing changes to the vault's git repository
+	Git vault.GitConfig
+	// Committer commits the command's changes when it finishes (nil when off)
+	Committer *vault.GitCommitter
+	// LockTimeout is how long to wait for notes other processes are using
+	LockTimeout time.Duration
+}
+
+// newReader returns a vault reader bound to the invocation's policy
+func newReader(deps *Dependencies) *vault.Reader {
+	reader := vault.NewReader(deps.VaultPath)
+	reader.SetPolicy(deps.Policy)
+	reader.SetLocker(newLocker(deps))
+	return reader
+}
+
+// newWriter returns a vault writer bound to the invocation's policy that
+// records its changes in the vault's journal, or only plans them with --dry-run
+func newWriter(deps *Dependencies) *vault.Writer {
+	writer := vault.NewWriter(deps.VaultPath)
+	writer.SetPolicy(deps.Policy)
+	writer.SetJournal(vault.NewJournal(deps.VaultPath))
+	writer.SetGit(deps.Committer)
+	writer.SetLocker(newLocker(deps))
+	writer = writer.WithSource("cli " + deps.Command)
+	if deps.DryRun {
+		return writer.DryRun()
+	}
+	return writer
+}
+
+// newLocker returns the vault's note locker with the invocation's timeout
+func newLocker(deps *Dependencies) *vault.NoteLocker {
+	locker := vault.NewNoteLocker(deps.VaultPath)
+	locker.SetTimeout(deps.LockTimeout)
+	return locker
+}
+
+// newOrphanFinder returns a gardener bound to the invocation's policy
+func newOrphanFinder(deps *Dependencies) *gardener.OrphanFinder {
+	finder := gardener.NewOrphanFinder(deps.VaultPath)
+	finder.SetPolicy(deps.Policy)
+	return finder
+}
+
+// parseFlags parses flags that may appear before, between or after
+// positional arguments, returning the positional arguments in order.
+// Arguments that merely start with a dash, such as "- item" or "-5", are
+// positional; everything after a "--" terminator is positional too.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional, flags []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !flagArgRegex.MatchString(arg) {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		// A flag's value may be the next argument, even if it starts with a dash
+		if !strings.Contains(arg, "=") && flagTakesValue(fs, arg) && i+1 < len(args) {
+			flags = append(flags, args[i+1])
+			i++
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		return nil, err
+	}
+	return positional, nil
+}
+
+// flagArgRegex matches arguments that look like -name, --name or --name=value
+var flagArgRegex = regexp.MustCompile(`^--?[A-Za-z][A-Za-z0-9_-]*(=|$)`)
+
+// flagTakesValue reports whether a defined flag expects a separate value
+func flagTakesValue(fs *flag.FlagSet, arg string) bool {
+	f := fs.Lookup(strings.TrimLeft(arg, "-"))
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 // RunRead implements US-001: Read a file
@@ -26,32 +138,20 @@ func RunRead(deps *Dependencies, args []string) error {
 	}
 	filename := args[0]
 
-	reader := vault.NewReader(deps.VaultPath)
-	content, err := reader.ReadNote(filename)
+	reader := newReader(deps)
+	path, content, version, err := reader.ReadNoteVersion(filename)
 	if err != nil {
 		return err
 	}
 
 	if deps.JsonOutput {
-		printJson(map[string]string{"content": content})
+		printJson(map[string]string{"path": path, "content": content, "version": version})
 	} else {
 		fmt.Println(content)
 	}
 	return nil
 }
 
-// RunCreate implements US-001: Create a file
-func RunCreate(deps *Dependencies, args []string) error {
-	// Simplified implementation for skeleton
-	if len(args) < 1 {
-		return fmt.Errorf("usage: create <path> (content via stdin or flag not impl yet in skeleton)")
-	}
-	// path := args[0]
-	// For now just error as "not fully implemented" or do a basic write if easy
-	// We will focus on search for now as the user requested US-002 primarily
-	return fmt.Errorf("create not yet fully implemented in recovery phase")
-}
-
 // RunSearch implements US-002: Simple text search
 func RunSearch(deps *Dependencies, args []string) error {
 	if len(args) < 1 {
@@ -63,6 +163,7 @@ func RunSearch(deps *Dependencies, args []string) error {
 	// For efficiency, we might want to use `internal/vault` if it has search capabilities,
 	// otherwise just walk the directory.
 
+	reader := newReader(deps)
 	var matches []string
 	err := filepath.Walk(deps.VaultPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -77,15 +178,18 @@ func RunSearch(deps *Dependencies, args []string) error {
 		if !strings.HasSuffix(info.Name(), ".md") {
 			return nil
 		}
+		rel, _ := filepath.Rel(deps.VaultPath, path)
+		if !deps.Policy.CanRead(rel) {
+			return nil
+		}
 
-		// Read file
-		content, err := os.ReadFile(path)
+		// Read through the reader so symlinks escaping the vault are skipped
+		content, err := reader.ReadNote(rel)
 		if err != nil {
 			return nil
 		}
 
-		if strings.Contains(strings.ToLower(string(content)), strings.ToLower(query)) {
-			rel, _ := filepath.Rel(deps.VaultPath, path)
+		if strings.Contains(strings.ToLower(content), strings.ToLower(query)) {
 			matches = append(matches, rel)
 		}
 		return nil
@@ -197,6 +301,27 @@ func printJson(v interface{}) {
 	enc.Encode(v)
 }
 
+// printDryRun shows the changes a dry run writer planned as unified diffs
+func printDryRun(deps *Dependencies, writer *vault.Writer) {
+	changes := writer.Planned()
+	if deps.JsonOutput {
+		entries := make([]map[string]string, len(changes))
+		for i, change := range changes {
+			entries[i] = map[string]string{"path": change.Path, "change": change.Kind(), "diff": change.Diff()}
+		}
+		printJson(map[string]interface{}{"dry_run": true, "changes": entries})
+		return
+	}
+	for _, change := range changes {
+		fmt.Print(change.Diff())
+	}
+	if len(changes) == 0 {
+		fmt.Println("ℹ️  Dry run: nothing would change")
+	} else {
+		fmt.Printf("ℹ️  Dry run: %d file(s) would change, nothing was written\n", len(changes))
+	}
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	// simplified
 	return defaultVal
@@ -204,7 +329,7 @@ func getEnvInt(key string, defaultVal int) int {
 
 // RunOrphans implements US-003: List orphan notes
 func RunOrphans(deps *Dependencies, args []string) error {
-	finder := gardener.NewOrphanFinder(deps.VaultPath)
+	finder := newOrphanFinder(deps)
 	orphans, err := finder.FindOrphans()
 	if err != nil {
 		return err
@@ -222,7 +347,7 @@ func RunOrphans(deps *Dependencies, args []string) error {
 
 // RunStats implements US-003: Vault statistics
 func RunStats(deps *Dependencies, args []string) error {
-	finder := gardener.NewOrphanFinder(deps.VaultPath)
+	finder := newOrphanFinder(deps)
 	stats, err := finder.GetLinkStats()
 	if err != nil {
 		return err
@@ -241,7 +366,7 @@ func RunStats(deps *Dependencies, args []string) error {
 
 // RunTags implements US-003: List all tags
 func RunTags(deps *Dependencies, args []string) error {
-	reader := vault.NewReader(deps.VaultPath)
+	reader := newReader(deps)
 	tags, err := reader.ListTags()
 	if err != nil {
 		return err
@@ -259,21 +384,49 @@ func RunTags(deps *Dependencies, args []string) error {
 
 // RunLink implements US-003: Create a wikilink
 func RunLink(deps *Dependencies, args []string) error {
-	if len(args) < 2 {
-		return fmt.Errorf("usage: link <source> <target>")
+	fs := flag.NewFlagSet("link", flag.ContinueOnError)
+	heading := fs.String("heading", vault.DefaultRelatedHeading, "Section to list the link under")
+	alias := fs.String("alias", "", "Display text for the link")
+	property := fs.String("property", "", "Add the link to this frontmatter list property instead")
+	both := fs.Bool("both", false, "Also link the target back to the source")
+	expectVersion := fs.String("expect-version", "", "Fail if the source note has changed since this version")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
 	}
-	source := args[0]
-	target := args[1]
+	if len(positional) < 2 {
+		return fmt.Errorf("usage: link [--heading H] [--alias TEXT] [--property KEY] [--both] [--expect-version V] <source> <target>")
+	}
+	source := positional[0]
+	target := positional[1]
 
-	writer := vault.NewWriter(deps.VaultPath)
-	if err := writer.LinkNotes(source, target); err != nil {
+	writer := newWriter(deps)
+	result, err := writer.LinkNotes(source, target, vault.LinkOptions{
+		Heading:         *heading,
+		Alias:           *alias,
+		Property:        *property,
+		Bidirectional:   *both,
+		ExpectedVersion: *expectVersion,
+	})
+	if err != nil {
 		return err
 	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
 
 	if deps.JsonOutput {
-		printJson(map[string]string{"status": "linked", "source": source, "target": target})
+		printJson(result)
+		return nil
+	}
+	if result.Added {
+		fmt.Printf("✓ Linked '%s' to %s\n", result.Source, result.Link)
 	} else {
-		fmt.Printf("Linked '%s' to '%s'\n", source, target)
+		fmt.Printf("ℹ️  '%s' already links to '%s'\n", result.Source, target)
+	}
+	if result.LinkedBack {
+		fmt.Printf("✓ Linked '%s' back to '%s'\n", result.Target, result.Source)
 	}
 	return nil
 }
@@ -303,9 +456,9 @@ func RunWatch(deps *Dependencies, args []string) error {
 	defer w.Close()
 
 	// 3. Set Callback
-	reader := vault.NewReader(deps.VaultPath)
+	reader := newReader(deps)
 	w.SetCallback(func(path string, op watcher.FileOp) {
-		relPath, err := filepath.Rel(deps.VaultPath, path)
+		relPath, err := reader.Paths().Rel(path)
 		if err != nil {
 			fmt.Printf("⚠️  Failed to get relative path: %v\n", err)
 			return
@@ -343,27 +496,32 @@ func RunWatch(deps *Dependencies, args []string) error {
 func indexNote(vecStore interface {
 	IndexDocument(id, title, content string) error
 }, reader *vault.Reader, path string) {
+	if err := indexDocument(vecStore, reader, path); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	} else {
+		fmt.Printf("✓ Indexed: %s\n", path)
+	}
+}
+
+// indexDocument indexes a note under its path, titled by its first heading
+func indexDocument(vecStore interface {
+	IndexDocument(id, title, content string) error
+}, reader *vault.Reader, path string) error {
 	content, err := reader.ReadNote(path)
 	if err != nil {
-		fmt.Printf("⚠️  Failed to read note: %v\n", err)
-		return
+		return fmt.Errorf("failed to read note: %w", err)
 	}
 
-	// Extract title from first heading or filename
-	title := filepath.Base(path)
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			title = strings.TrimPrefix(line, "# ")
-			break
-		}
+	// Title from first heading or filename
+	title := vault.ParseDocument(content).Title()
+	if title == "" {
+		title = filepath.Base(path)
 	}
 
 	if err := vecStore.IndexDocument(path, title, content); err != nil {
-		fmt.Printf("⚠️  Failed to index: %v\n", err)
-	} else {
-		fmt.Printf("✓ Indexed: %s\n", path)
+		return fmt.Errorf("failed to index: %w", err)
 	}
+	return nil
 }
 
 // RunIndex implements Bulk Index Command
@@ -383,7 +541,7 @@ func RunIndex(deps *Dependencies, args []string) error {
 		fmt.Printf("✓ Connected to vector store (Documents: %d)\n", count)
 	}
 
-	reader := vault.NewReader(deps.VaultPath)
+	reader := newReader(deps)
 	count := 0
 
 	fmt.Printf("📂 Scanning vault: %s\n", deps.VaultPath)
@@ -403,6 +561,9 @@ func RunIndex(deps *Dependencies, args []string) error {
 
 		if !info.IsDir() && strings.HasSuffix(path, ".md") {
 			relPath, _ := filepath.Rel(deps.VaultPath, path)
+			if !deps.Policy.CanRead(relPath) {
+				return nil
+			}
 			fmt.Printf("Indexing: %s\n", relPath)
 			indexNote(store, reader, relPath)
 			count++
@@ -420,20 +581,32 @@ func RunIndex(deps *Dependencies, args []string) error {
 
 // RunAppend implements Append Command
 func RunAppend(deps *Dependencies, args []string) error {
+	// Flags must come first so text such as "-- done" is appended verbatim
+	fs := flag.NewFlagSet("append", flag.ContinueOnError)
+	expectVersion := fs.String("expect-version", "", "Fail if the note has changed since this version")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
 	if len(args) < 2 {
-		return fmt.Errorf("usage: append <filename> <text>")
+		return fmt.Errorf("usage: append [--expect-version V] <filename> <text>")
 	}
 	filename := args[0]
 	// Join remaining args as text, preserving spaces
 	text := strings.Join(args[1:], " ")
 
-	writer := vault.NewWriter(deps.VaultPath)
-	if err := writer.AppendToNote(filename, text); err != nil {
+	writer := newWriter(deps)
+	version, err := writer.AppendToNote(filename, text, *expectVersion)
+	if err != nil {
 		return err
 	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
 
 	if deps.JsonOutput {
-		printJson(map[string]string{"status": "appended", "file": filename})
+		printJson(map[string]string{"status": "appended", "file": filename, "version": version})
 	} else {
 		fmt.Printf("✓ Appended to '%s'\n", filename)
 	}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// RunCreate implements US-001: Create a file
+//
+//	create Inbox/Idea --content "Body text"
+//	echo "Body" | create Inbox/Idea --prop tags=[idea,draft] --prop due=2025-03-01
+//	create Meetings/Standup --template Meeting --var project=Atlas --if-missing
+func RunCreate(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	content := fs.String("content", "", "Note body (default: read from stdin when piped)")
+	var props stringList
+	fs.Var(&props, "prop", "Frontmatter property as key=value; repeat for more (YAML typed: 3, true, [a, b], 2025-01-31)")
+	propsFile := fs.String("props-file", "", "YAML file of frontmatter properties")
+	template := fs.String("template", "", "Template note to start from (default: the folder's template, if any)")
+	var vars stringList
+	fs.Var(&vars, "var", "Template variable as name=value; repeat for more")
+	overwrite := fs.Bool("overwrite", false, "Replace the note if it already exists")
+	ifMissing := fs.Bool("if-missing", false, "Do nothing if the note already exists")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: create <path> [--content TEXT] [--prop key=value]... [--props-file FILE] [--template NOTE] [--var name=value]... [--overwrite|--if-missing]")
+	}
+	if *overwrite && *ifMissing {
+		return fmt.Errorf("--overwrite and --if-missing cannot be combined")
+	}
+
+	body := *content
+	if body == "" && stdinIsPiped() {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read content from stdin: %w", err)
+		}
+		body = string(data)
+	}
+
+	frontmatter, err := loadProperties(*propsFile, props)
+	if err != nil {
+		return err
+	}
+	templateVars := make(map[string]interface{})
+	for _, assignment := range vars {
+		name, value, err := vault.ParseTemplateVar(assignment)
+		if err != nil {
+			return err
+		}
+		templateVars[name] = value
+	}
+
+	opts := vault.CreateOptions{
+		Frontmatter: frontmatter,
+		Template:    *template,
+		Vars:        templateVars,
+		IfExists:    vault.ExistsFail,
+	}
+	if *overwrite {
+		opts.IfExists = vault.ExistsOverwrite
+	} else if *ifMissing {
+		opts.IfExists = vault.ExistsSkip
+	}
+
+	writer := newWriter(deps)
+	path, status, err := writer.CreateNoteWithOptions(positional[0], body, opts)
+	if err != nil {
+		return err
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+
+	if deps.JsonOutput {
+		printJson(map[string]string{"status": string(status), "path": path})
+		return nil
+	}
+	switch status {
+	case vault.StatusSkipped:
+		fmt.Printf("ℹ️  '%s' already exists\n", path)
+	case vault.StatusOverwritten:
+		fmt.Printf("✓ Overwrote '%s'\n", path)
+	default:
+		fmt.Printf("✓ Created '%s'\n", path)
+	}
+	return nil
+}
+
+// loadProperties builds frontmatter from a YAML file and key=value
+// assignments. Assignments override the file; repeating a key builds a list.
+func loadProperties(file string, assignments []string) (vault.Frontmatter, error) {
+	fm := make(vault.Frontmatter)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read properties file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &fm); err != nil {
+			return nil, fmt.Errorf("failed to parse properties file: %w", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, assignment := range assignments {
+		key, value, err := vault.ParseProperty(assignment)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			fm[key] = appendValue(fm[key], value)
+		} else {
+			fm[key] = value
+		}
+		seen[key] = true
+	}
+	return fm, nil
+}
+
+// appendValue adds value to an existing property, turning it into a list
+func appendValue(existing, value interface{}) interface{} {
+	list, ok := existing.([]interface{})
+	if !ok {
+		list = []interface{}{existing}
+	}
+	if values, ok := value.([]interface{}); ok {
+		return append(list, values...)
+	}
+	return append(list, value)
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const editUsage = "usage: edit <note> (--under HEADING | --replace-section HEADING | --prepend | --block ID) [text]\n" +
+	"       edit <note> --find TEXT [--regex] --replace TEXT"
+
+// RunEdit edits part of an existing note. The text comes from the
+// arguments after the note, or from stdin when piped.
+//
+//	edit Projects/Plan --under "## Log" "- Shipped the importer"
+//	edit Projects/Plan --replace-section Status "On track"
+//	edit Projects/Plan --prepend "> [!note] Archived"
+//	edit Projects/Plan --block summary "New summary"
+//	edit Projects/Plan --find "TODO" --replace "DONE"
+//	edit Projects/Plan --find "v(\d+)" --regex --replace 'version $1'
+func RunEdit(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	under := fs.String("under", "", "Insert the text at the end of this heading's section, adding the heading if missing")
+	section := fs.String("replace-section", "", "Replace the body of this heading's section with the text")
+	prepend := fs.Bool("prepend", false, "Insert the text at the start of the note, after the frontmatter")
+	block := fs.String("block", "", "Replace the block marked with this ^block-id with the text")
+	find := fs.String("find", "", "Text to find")
+	replace := fs.String("replace", "", "Replacement for --find")
+	regex := fs.Bool("regex", false, "Treat --find as a regular expression; --replace may use $1")
+	expectVersion := fs.String("expect-version", "", "Fail if the note has changed since this version")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return fmt.Errorf(editUsage)
+	}
+	note := positional[0]
+
+	modes := 0
+	for _, set := range []bool{*under != "", *section != "", *prepend, *block != "", *find != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return fmt.Errorf("choose exactly one edit\n%s", editUsage)
+	}
+
+	writer := newWriter(deps)
+
+	if *find != "" {
+		replaceSet := false
+		fs.Visit(func(f *flag.Flag) {
+			replaceSet = replaceSet || f.Name == "replace"
+		})
+		if !replaceSet {
+			return fmt.Errorf("--find needs --replace (use --replace \"\" to delete)")
+		}
+		version, count, err := writer.ReplaceInNote(note, *find, *replace, *regex, *expectVersion)
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		if deps.JsonOutput {
+			printJson(map[string]interface{}{"status": "edited", "file": note, "version": version, "replacements": count})
+		} else if count == 0 {
+			fmt.Printf("ℹ️  No matches in '%s'\n", note)
+		} else {
+			fmt.Printf("✓ Replaced %d occurrence(s) in '%s'\n", count, note)
+		}
+		return nil
+	}
+
+	text := strings.Join(positional[1:], " ")
+	if text == "" && stdinIsPiped() {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read text from stdin: %w", err)
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" && *section == "" {
+		return fmt.Errorf("no text given\n%s", editUsage)
+	}
+
+	var version string
+	switch {
+	case *under != "":
+		version, err = writer.InsertUnderHeading(note, *under, text, *expectVersion)
+	case *section != "":
+		version, err = writer.ReplaceSection(note, *section, text, *expectVersion)
+	case *prepend:
+		version, err = writer.PrependToNote(note, text, *expectVersion)
+	default:
+		version, err = writer.ReplaceBlock(note, *block, text, *expectVersion)
+	}
+	if err != nil {
+		return err
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+
+	if deps.JsonOutput {
+		printJson(map[string]string{"status": "edited", "file": note, "version": version})
+	} else {
+		fmt.Printf("✓ Edited '%s'\n", note)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+// RunHistory lists the changes recorded in the vault's journal, newest first
+//
+//	history
+//	history --limit 50
+func RunHistory(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "Number of operations to show (0 for all)")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ops, err := vault.NewJournal(deps.VaultPath).List(*limit)
+	if err != nil {
+		return err
+	}
+	if deps.JsonOutput {
+		entries := make([]map[string]interface{}, len(ops))
+		for i, op := range ops {
+			entries[i] = operationJson(op)
+		}
+		printJson(entries)
+		return nil
+	}
+	if len(ops) == 0 {
+		fmt.Println("ℹ️  No changes recorded")
+		return nil
+	}
+	for _, op := range ops {
+		status := ""
+		switch {
+		case op.UndoneBy != "":
+			status = " (undone by " + op.UndoneBy + ")"
+		case op.UndoOf != "":
+			status = " (undoes " + op.UndoOf + ")"
+		}
+		fmt.Printf("%s  %s  %-11s %-18s %s%s\n", op.ID, op.Time.Format("2006-01-02 15:04:05"), op.Action, op.Source, strings.Join(op.Paths(), ", "), status)
+	}
+	return nil
+}
+
+// RunUndo reverts recorded operations, refusing when a note they changed
+// has been edited since
+//
+//	undo 3f9a2c1e
+//	undo --last 3
+func RunUndo(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	last := fs.Int("last", 0, "Undo the N most recent operations not already undone")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if (len(positional) == 1) == (*last > 0) || len(positional) > 1 {
+		return fmt.Errorf("usage: undo <operation id> | undo --last N")
+	}
+
+	ids := positional
+	if *last > 0 {
+		ops, err := vault.NewJournal(deps.VaultPath).List(0)
+		if err != nil {
+			return err
+		}
+		// Undos are skipped so repeated undos keep stepping back
+		for _, op := range ops {
+			if len(ids) == *last {
+				break
+			}
+			if op.UndoneBy == "" && op.UndoOf == "" {
+				ids = append(ids, op.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("nothing to undo")
+		}
+	}
+
+	writer := newWriter(deps)
+	var undone []map[string]interface{}
+	for _, id := range ids {
+		op, err := writer.Undo(id)
+		if err != nil {
+			if deps.JsonOutput && len(undone) > 0 {
+				printJson(map[string]interface{}{"status": "partial", "undone": undone, "error": err.Error()})
+			}
+			return err
+		}
+		if deps.JsonOutput || writer.IsDryRun() {
+			undone = append(undone, operationJson(op))
+			continue
+		}
+		fmt.Printf("✓ Undid %s (%s %s)\n", op.ID, op.Source, op.Action)
+		for _, path := range op.Paths() {
+			fmt.Printf("   %s\n", path)
+		}
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+	if deps.JsonOutput {
+		printJson(map[string]interface{}{"status": "undone", "undone": undone})
+	}
+	return nil
+}
+
+// operationJson describes an operation without the notes' contents
+func operationJson(op *vault.Operation) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        op.ID,
+		"time":      op.Time,
+		"source":    op.Source,
+		"action":    op.Action,
+		"paths":     op.Paths(),
+		"undo_of":   op.UndoOf,
+		"undone_by": op.UndoneBy,
+	}
+}
+
+// RunChanges summarizes the commits agents made to the vault's git
+// repository since a revision, as recorded with --git-commit
+//
+//	changes --since HEAD~10
+//	changes --since v1.0
+func RunChanges(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("changes", flag.ContinueOnError)
+	since := fs.String("since", "", "Revision to list agent commits after")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *since == "" && len(positional) == 1 {
+		*since = positional[0]
+	}
+	if *since == "" {
+		return fmt.Errorf("usage: changes --since <rev>")
+	}
+
+	repo, err := vault.OpenGitRepo(deps.VaultPath)
+	if err != nil {
+		return err
+	}
+	commits, err := repo.AgentCommits(*since)
+	if err != nil {
+		return err
+	}
+	notes := vault.ChangedNotes(commits)
+	if deps.JsonOutput {
+		printJson(map[string]interface{}{"since": *since, "commits": commits, "notes": notes})
+		return nil
+	}
+	if len(commits) == 0 {
+		fmt.Printf("ℹ️  No agent commits since %s\n", *since)
+		return nil
+	}
+	for _, commit := range commits {
+		fmt.Printf("%s  %s  %s\n", commit.Hash[:8], commit.Time.Local().Format("2006-01-02 15:04"), commit.Subject)
+		for _, file := range commit.Files {
+			fmt.Printf("   %s %s\n", file.Status, file.Path)
+		}
+	}
+	fmt.Printf("ℹ️  %d agent commit(s) since %s changed %d note(s)\n", len(commits), *since, len(notes))
+	return nil
+}
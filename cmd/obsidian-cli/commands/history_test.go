@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+func TestUndoLastSkipsUndos(t *testing.T) {
+	vaultPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(vaultPath, "Plan.md"), []byte("start\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		data, err := os.ReadFile(filepath.Join(vaultPath, "Plan.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	deps := &Dependencies{VaultPath: vaultPath, Command: "test"}
+	writer := newWriter(deps)
+	versions := []string{read()}
+	for _, text := range []string{"one", "two", "three"} {
+		if _, err := writer.AppendToNote("Plan", text, ""); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, read())
+	}
+
+	// Each undo --last steps further back instead of undoing the last undo
+	for _, want := range []string{versions[2], versions[1]} {
+		if err := RunUndo(deps, []string{"--last", "1"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := read(); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	if err := RunUndo(deps, []string{"--last", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != versions[0] {
+		t.Errorf("got %q after undoing the rest, want the original", got)
+	}
+	if err := RunUndo(deps, []string{"--last", "1"}); err == nil {
+		t.Error("got no error with nothing left to undo")
+	}
+
+	ops, err := vault.NewJournal(vaultPath).List(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 6 {
+		t.Errorf("journal has %d operations, want 3 appends and 3 undos", len(ops))
+	}
+}
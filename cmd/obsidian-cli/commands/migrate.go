@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+// RunMigrate runs a YAML migration spec over the vault's properties. It
+// previews the changes as a unified diff unless --apply is given, in which
+// case every change is written or, if one fails, none is.
+//
+//	migrate tags-to-lists.yaml
+//	migrate tags-to-lists.yaml --apply
+func RunMigrate(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "Write the changes instead of previewing them")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: migrate <spec.yaml> [--apply]")
+	}
+
+	migration, err := vault.LoadMigration(positional[0])
+	if err != nil {
+		return err
+	}
+	writer := newWriter(deps)
+	result, err := writer.PlanMigration(migration)
+	if err != nil {
+		return err
+	}
+	// A migration without --apply is already a dry run
+	if *apply && !deps.DryRun && len(result.Changes) > 0 {
+		if err := writer.ApplyMigration(result); err != nil {
+			return err
+		}
+	}
+
+	if deps.JsonOutput {
+		printJson(result)
+		return nil
+	}
+	if !result.Applied {
+		for _, change := range result.Changes {
+			fmt.Print(change.Diff)
+		}
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Printf("⚠️  Skipped %s\n", skipped)
+	}
+	switch {
+	case len(result.Changes) == 0:
+		fmt.Printf("ℹ️  Nothing to change (%d of %d notes matched)\n", result.Matched, result.Scanned)
+	case result.Applied:
+		fmt.Printf("✓ Migrated %d note(s) (%d of %d notes matched)\n", len(result.Changes), result.Matched, result.Scanned)
+	default:
+		fmt.Printf("%d note(s) would change (%d of %d notes matched); run with --apply to write them\n", len(result.Changes), result.Matched, result.Scanned)
+	}
+	return nil
+}
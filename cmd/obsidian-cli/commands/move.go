@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vectorstore"
+)
+
+// RunMove renames or moves a note and rewrites every link to it
+//
+//	mv "Projects/Plan" "Archive/2025 Plan"
+//	mv Inbox/Idea Projects/
+func RunMove(deps *Dependencies, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: mv <note> <new path or folder/>")
+	}
+
+	writer := newWriter(deps)
+	result, err := writer.MoveNote(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+
+	// Keep the note's embeddings under its new path; a vector store that
+	// isn't running is caught up by the next index or watch
+	indexed := updateIndex(func(store *vectorstore.QdrantStore) error {
+		return store.RenameDocument(result.From, result.To)
+	})
+
+	if deps.JsonOutput {
+		printJson(map[string]interface{}{
+			"status":  "moved",
+			"from":    result.From,
+			"to":      result.To,
+			"updated": result.Updated,
+			"links":   result.Links,
+			"skipped": result.Skipped,
+			"indexed": indexed,
+		})
+		return nil
+	}
+	fmt.Printf("✓ Moved '%s' to '%s'\n", result.From, result.To)
+	if len(result.Updated) > 0 {
+		fmt.Printf("🔗 Updated %d link(s) in %d note(s):\n", result.Links, len(result.Updated))
+		for _, note := range result.Updated {
+			fmt.Printf("   %s\n", note)
+		}
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("⚠️  Left links in %d note(s) the access policy doesn't let agents write:\n", len(result.Skipped))
+		for _, note := range result.Skipped {
+			fmt.Printf("   %s\n", note)
+		}
+	}
+	return nil
+}
+
+// updateIndex applies a change to the vector store, reporting whether it
+// succeeded. The vault change has already happened, so an unreachable store
+// is only a warning; the next index or watch catches up.
+func updateIndex(update func(store *vectorstore.QdrantStore) error) bool {
+	config := vectorstore.QdrantConfig{
+		Host: os.Getenv("QDRANT_HOST"),
+		Port: getEnvInt("QDRANT_PORT", 6334),
+	}
+	store, err := vectorstore.NewQdrantStore(config, vectorstore.NewEmbedderAuto())
+	if err == nil {
+		err = update(store)
+		store.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Vector store not updated: %v\n", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+const periodicUsage = "usage: periodic <read|append|create> <day|week|month|quarter|year> [--date DATE] [date|text]"
+
+// RunPeriodic reads, appends to or creates daily, weekly, monthly, quarterly
+// and yearly notes
+//
+//	periodic read week
+//	periodic read month 2025-01
+//	periodic append month "Shipped the importer"
+//	periodic append week --date last "Retro notes"
+//	periodic create quarter next
+func RunPeriodic(deps *Dependencies, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf(periodicUsage)
+	}
+	action := args[0]
+	period, err := vault.ParsePeriod(args[1])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("periodic", flag.ContinueOnError)
+	dateExpr := fs.String("date", "", "Date in the period: YYYY-MM-DD, YYYY-Www, YYYY-MM, YYYY-Qn, YYYY, last or next")
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+	rest := fs.Args()
+
+	// read and create take the date positionally as well
+	if action != "append" && *dateExpr == "" && len(rest) > 0 {
+		*dateExpr = rest[0]
+	}
+	date, err := vault.ParsePeriodDate(period, *dateExpr, time.Now())
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "read":
+		reader := newReader(deps)
+		path, err := reader.PeriodicNotePath(period, date)
+		if err != nil {
+			return err
+		}
+		content, err := reader.GetPeriodicNote(period, date)
+		if err != nil {
+			return err
+		}
+		if deps.JsonOutput {
+			printJson(map[string]string{"path": path, "content": content})
+		} else {
+			fmt.Println(content)
+		}
+
+	case "append":
+		if len(rest) == 0 {
+			return fmt.Errorf(periodicUsage)
+		}
+		writer := newWriter(deps)
+		path, err := writer.AppendToPeriodicNote(period, date, strings.Join(rest, " "))
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		if deps.JsonOutput {
+			printJson(map[string]string{"status": "appended", "file": path})
+		} else {
+			fmt.Printf("✓ Appended to '%s'\n", path)
+		}
+
+	case "create":
+		writer := newWriter(deps)
+		path, created, err := writer.CreatePeriodicNote(period, date)
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		status := "created"
+		if !created {
+			status = "exists"
+		}
+		if deps.JsonOutput {
+			printJson(map[string]string{"status": status, "file": path})
+		} else if created {
+			fmt.Printf("✓ Created '%s'\n", path)
+		} else {
+			fmt.Printf("ℹ️  '%s' already exists\n", path)
+		}
+
+	default:
+		return fmt.Errorf(periodicUsage)
+	}
+	return nil
+}
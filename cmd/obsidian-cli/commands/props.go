@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+const propsUsage = "usage: props get|unset <note> <key>\n" +
+	"       props set|add|remove <note> <key> <value>... [--type TYPE]\n" +
+	"       props list"
+
+// RunProps reads and edits note properties. Values are typed by the
+// property's type in .obsidian/types.json, or inferred from the text.
+//
+//	props get Projects/Plan status
+//	props set Projects/Plan due 2025-03-01
+//	props set Projects/Plan priority 2 --type number
+//	props add Projects/Plan tags project active
+//	props remove Projects/Plan aliases "Old Plan"
+//	props unset Projects/Plan draft
+//	props list
+func RunProps(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("props", flag.ContinueOnError)
+	typeName := fs.String("type", "", "Property type: text, list, number, checkbox, date or datetime")
+	expectVersion := fs.String("expect-version", "", "Fail if the note has changed since this version")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errors.New(propsUsage)
+	}
+	action := positional[0]
+
+	if action == "list" {
+		properties, err := newReader(deps).ListProperties()
+		if err != nil {
+			return err
+		}
+		if deps.JsonOutput {
+			printJson(properties)
+			return nil
+		}
+		for _, p := range properties {
+			fmt.Printf("%-24s %-10s %d\n", p.Name, p.Type, p.Count)
+		}
+		return nil
+	}
+
+	if len(positional) < 3 {
+		return errors.New(propsUsage)
+	}
+	note, key, values := positional[1], positional[2], positional[3:]
+
+	types, err := vault.LoadPropertyTypes(deps.VaultPath)
+	if err != nil {
+		return err
+	}
+	typ, declared := types[key]
+	if *typeName != "" {
+		if typ, err = vault.ParsePropertyType(*typeName); err != nil {
+			return err
+		}
+		declared = true
+	}
+
+	writer := newWriter(deps)
+	switch action {
+	case "get":
+		path, content, version, err := newReader(deps).ReadNoteVersion(note)
+		if err != nil {
+			return err
+		}
+		fm, err := vault.EditFrontmatter(content)
+		if err != nil {
+			return err
+		}
+		value, ok := fm.Get(key)
+		if !ok {
+			return fmt.Errorf("%s has no %q property", path, key)
+		}
+		if deps.JsonOutput {
+			printJson(map[string]interface{}{"path": path, "key": key, "value": value, "version": version})
+		} else if list, ok := value.([]interface{}); ok {
+			for _, item := range list {
+				fmt.Println(item)
+			}
+		} else if value != nil {
+			fmt.Println(value)
+		}
+
+	case "set":
+		if len(values) == 0 {
+			return errors.New(propsUsage)
+		}
+		var value interface{}
+		if declared && typ.IsList() {
+			// Each argument is an item
+			value, err = listValues(typ, values)
+		} else if declared {
+			value, err = vault.ConvertPropertyValue(typ, strings.Join(values, " "))
+		} else {
+			value, err = vault.ParsePropertyValue(strings.Join(values, " "))
+		}
+		if err != nil {
+			return err
+		}
+		version, err := writer.UpdateFrontmatter(note, key, value, *expectVersion)
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		if deps.JsonOutput {
+			printJson(map[string]interface{}{"status": "updated", "file": note, "key": key, "value": value, "version": version})
+		} else {
+			fmt.Printf("✓ Set %s on '%s'\n", key, note)
+		}
+
+	case "add", "remove":
+		if len(values) == 0 {
+			return errors.New(propsUsage)
+		}
+		if declared && !typ.IsList() {
+			return fmt.Errorf("%s is a %s property, not a list", key, typ)
+		}
+		if !declared {
+			typ = vault.PropertyList
+		}
+		items, err := listValues(typ, values)
+		if err != nil {
+			return err
+		}
+
+		var version string
+		var count int
+		if action == "add" {
+			version, count, err = writer.AddPropertyValues(note, key, items, *expectVersion)
+		} else {
+			version, count, err = writer.RemovePropertyValues(note, key, items, *expectVersion)
+		}
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		if deps.JsonOutput {
+			printJson(map[string]interface{}{"status": "updated", "file": note, "key": key, "changed": count, "version": version})
+		} else if action == "add" {
+			fmt.Printf("✓ Added %d value(s) to %s in '%s'\n", count, key, note)
+		} else {
+			fmt.Printf("✓ Removed %d value(s) from %s in '%s'\n", count, key, note)
+		}
+
+	case "unset":
+		version, found, err := writer.UnsetProperty(note, key, *expectVersion)
+		if err != nil {
+			return err
+		}
+		if writer.IsDryRun() {
+			printDryRun(deps, writer)
+			return nil
+		}
+		if deps.JsonOutput {
+			printJson(map[string]interface{}{"status": "updated", "file": note, "key": key, "removed": found, "version": version})
+		} else if found {
+			fmt.Printf("✓ Removed %s from '%s'\n", key, note)
+		} else {
+			fmt.Printf("ℹ️  '%s' has no %s property\n", note, key)
+		}
+
+	default:
+		return fmt.Errorf("unknown props action %q\n%s", action, propsUsage)
+	}
+	return nil
+}
+
+// listValues converts each argument to list items of a list property type
+func listValues(typ vault.PropertyType, args []string) ([]interface{}, error) {
+	items := []interface{}{}
+	for _, arg := range args {
+		value, err := vault.ConvertPropertyValue(typ, arg)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value.([]interface{})...)
+	}
+	return items, nil
+}
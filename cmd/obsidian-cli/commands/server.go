@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/mcp"
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vectorstore"
+	"github.com/chadmowery/obsidian-agent-tools/internal/watcher"
+)
+
+// RunServer runs the MCP server over stdio for Claude Desktop and other local agents
+func RunServer(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	httpMode := fs.Bool("http", false, "Serve the Streamable HTTP transport instead of stdio")
+	noWatch := fs.Bool("no-watch", false, "Don't watch the vault for resource change notifications")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *httpMode {
+		httpArgs := fs.Args()
+		if *noWatch {
+			httpArgs = append([]string{"--no-watch"}, httpArgs...)
+		}
+		return RunServerHTTP(deps, httpArgs)
+	}
+
+	server := newMCPServer(deps)
+	if !*noWatch {
+		stopWatching, err := watchResources(deps, server)
+		if err != nil {
+			return err
+		}
+		defer stopWatching()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// stdout carries the protocol, so all diagnostics go to stderr
+	fmt.Fprintf(os.Stderr, "✓ MCP server ready on stdio (vault: %s)\n", deps.VaultPath)
+	if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && err != context.Canceled {
+		return fmt.Errorf("mcp server error: %w", err)
+	}
+	return nil
+}
+
+// RunServerHTTP runs the MCP server over the Streamable HTTP transport
+func RunServerHTTP(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("server-http", flag.ContinueOnError)
+	host := fs.String("host", getEnvString("MCP_HTTP_HOST", "127.0.0.1"), "Interface to listen on")
+	port := fs.String("port", getEnvString("MCP_HTTP_PORT", "8080"), "Port to listen on")
+	path := fs.String("path", "/mcp", "Endpoint path")
+	noWatch := fs.Bool("no-watch", false, "Don't watch the vault for resource change notifications")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	server := newMCPServer(deps)
+	if !*noWatch {
+		stopWatching, err := watchResources(deps, server)
+		if err != nil {
+			return err
+		}
+		defer stopWatching()
+	}
+	handler := server.NewHTTPHandler(mcp.HTTPConfig{
+		AuthToken:      os.Getenv("MCP_HTTP_TOKEN"),
+		AllowedOrigins: policy.SplitList(os.Getenv("MCP_HTTP_ALLOWED_ORIGINS")),
+	})
+	defer handler.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, handler)
+
+	addr := net.JoinHostPort(*host, *port)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "✓ MCP server listening on http://%s%s (vault: %s)\n", addr, *path, deps.VaultPath)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("mcp http server error: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		// Close open SSE streams first so Shutdown doesn't wait on them
+		handler.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// newMCPServer builds the vault MCP server shared by all transports
+func newMCPServer(deps *Dependencies) *mcp.Server {
+	return mcp.NewVaultServer(mcp.Config{
+		VaultPath:     deps.VaultPath,
+		Version:       deps.Version,
+		PromptsFolder: promptsFolder(),
+		Policy:        deps.Policy,
+		Git:           deps.Git,
+		LockTimeout:   deps.LockTimeout,
+		OpenVectorStore: func() (vectorstore.VectorStore, error) {
+			config := vectorstore.QdrantConfig{
+				Host: os.Getenv("QDRANT_HOST"),
+				Port: getEnvInt("QDRANT_PORT", 6334),
+			}
+			return vectorstore.NewQdrantStore(config, vectorstore.NewEmbedderAuto())
+		},
+	})
+}
+
+// promptsFolder returns the vault folder holding MCP prompt notes
+func promptsFolder() string {
+	return getEnvString("MCP_PROMPTS_FOLDER", mcp.DefaultPromptsFolder)
+}
+
+// watchResources forwards vault changes to subscribed MCP clients as
+// resource and prompt notifications. The returned function stops the watcher.
+func watchResources(deps *Dependencies, server *mcp.Server) (func(), error) {
+	promptsPrefix := filepath.Clean(promptsFolder()) + string(filepath.Separator)
+
+	w, err := watcher.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize watcher: %w", err)
+	}
+
+	w.SetCallback(func(path string, op watcher.FileOp) {
+		relPath, err := filepath.Rel(deps.VaultPath, path)
+		if err != nil {
+			return
+		}
+		if strings.HasPrefix(relPath, promptsPrefix) {
+			server.NotifyPromptListChanged()
+		}
+
+		switch op {
+		case watcher.OpModify:
+			server.NotifyResourceUpdated(mcp.NoteURI(relPath))
+		case watcher.OpCreate:
+			server.NotifyResourceListChanged()
+		case watcher.OpDelete:
+			server.NotifyResourceUpdated(mcp.NoteURI(relPath))
+			server.NotifyResourceListChanged()
+		}
+	})
+
+	if err := w.Start(deps.VaultPath); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to start watcher: %w", err)
+	}
+	return w.Close, nil
+}
+
+// getEnvString returns an environment variable or a default
+func getEnvString(key, defaultVal string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return defaultVal
+}
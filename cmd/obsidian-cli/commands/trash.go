@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vectorstore"
+)
+
+// RunRemove deletes a note following the vault's "Deleted files" setting
+// and lists the notes that still link to it
+//
+//	rm Inbox/Scratch
+//	rm Inbox/Scratch --trash local
+func RunRemove(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+	trashFlag := fs.String("trash", "", "Where deleted notes go: system, local (.trash) or none (default: the vault's setting)")
+	permanent := fs.Bool("permanent", false, "Delete permanently; same as --trash none")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rm <note> [--trash system|local|none] [--permanent]")
+	}
+
+	var trash vault.TrashOption
+	if *permanent {
+		trash = vault.TrashNone
+	} else if *trashFlag != "" {
+		if trash, err = vault.ParseTrashOption(*trashFlag); err != nil {
+			return err
+		}
+	}
+
+	writer := newWriter(deps)
+	result, err := writer.DeleteNote(positional[0], trash)
+	if err != nil {
+		return err
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+	indexed := updateIndex(func(store *vectorstore.QdrantStore) error {
+		return store.RemoveDocument(result.Path)
+	})
+
+	if deps.JsonOutput {
+		printJson(map[string]interface{}{
+			"status":    "deleted",
+			"path":      result.Path,
+			"trash":     result.Trash,
+			"location":  result.Location,
+			"backlinks": result.Backlinks,
+			"indexed":   indexed,
+		})
+		return nil
+	}
+	switch result.Trash {
+	case vault.TrashNone:
+		fmt.Printf("🗑️  Permanently deleted '%s'\n", result.Path)
+	default:
+		fmt.Printf("🗑️  Moved '%s' to %s\n", result.Path, result.Location)
+	}
+	if len(result.Backlinks) > 0 {
+		fmt.Printf("⚠️  %d note(s) still link to it:\n", len(result.Backlinks))
+		for _, note := range result.Backlinks {
+			fmt.Printf("   %s\n", note)
+		}
+	}
+	return nil
+}
+
+// RunRestore brings a note back from the vault's .trash folder and re-indexes it
+//
+//	restore Inbox/Scratch
+//	restore Scratch --to Projects/Scratch
+func RunRestore(deps *Dependencies, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dest := fs.String("to", "", "Restore to this path instead of the original location")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: restore <note> [--to PATH]")
+	}
+
+	writer := newWriter(deps)
+	path, err := writer.RestoreNote(positional[0], *dest)
+	if err != nil {
+		return err
+	}
+	if writer.IsDryRun() {
+		printDryRun(deps, writer)
+		return nil
+	}
+	indexed := updateIndex(func(store *vectorstore.QdrantStore) error {
+		return indexDocument(store, newReader(deps), path)
+	})
+
+	if deps.JsonOutput {
+		printJson(map[string]interface{}{"status": "restored", "path": path, "indexed": indexed})
+	} else {
+		fmt.Printf("♻️  Restored '%s'\n", path)
+	}
+	return nil
+}
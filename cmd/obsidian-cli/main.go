@@ -1,24 +1,38 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/chadmowery/obsidian-agent-tools/cmd/obsidian-cli/commands"
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
 
 	"github.com/joho/godotenv"
 )
 
+// version is set at build time by goreleaser
+var version = "dev"
+
 func main() {
 	// 1. Load Environment
 	loadEnv()
 
 	// 2. Parse Global Flags
 	vaultPath := flag.String("vault", os.Getenv("OBSIDIAN_VAULT_PATH"), "Path to Obsidian vault")
+	accessPolicy := policy.FromEnv()
+	accessPolicy.RegisterFlags(flag.CommandLine)
+	gitConfig := vault.GitConfigFromEnv()
+	gitConfig.RegisterFlags(flag.CommandLine)
+	lockTimeout := flag.String("lock-timeout", os.Getenv("OBSIDIAN_LOCK_TIMEOUT"), "How long to wait for a note another process is changing (default 5s)")
 	jsonOutput := flag.Bool("json", false, "Output results as JSON")
+	dryRun := flag.Bool("dry-run", false, "Show the changes write commands would make as unified diffs, without making them")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: obsidian-cli [global flags] <command> [arguments]\n")
 		fmt.Fprintf(os.Stderr, "\nGlobal Flags:\n")
@@ -28,7 +42,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  search-semantic <query> Semantic search using vector embeddings\n")
 		fmt.Fprintf(os.Stderr, "  ask <question>          Ask a question about your notes (RAG)\n")
 		fmt.Fprintf(os.Stderr, "  read <file>             Read a note\n")
-		fmt.Fprintf(os.Stderr, "  create <path>           Create a note\n")
+		fmt.Fprintf(os.Stderr, "  create <path>           Create a note (content from --content or stdin)\n")
 		fmt.Fprintf(os.Stderr, "  orphans                 List notes with no links\n")
 		fmt.Fprintf(os.Stderr, "  tags                    List all tags\n")
 		fmt.Fprintf(os.Stderr, "  stats                   Show vault statistics\n")
@@ -36,6 +50,20 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  watch                   Watch vault for changes and auto-index\n")
 		fmt.Fprintf(os.Stderr, "  index                   Bulk index all notes\n")
 		fmt.Fprintf(os.Stderr, "  append <file> <text>    Append text to a note\n")
+		fmt.Fprintf(os.Stderr, "  mv <file> <new path>    Rename or move a note, updating links to it\n")
+		fmt.Fprintf(os.Stderr, "  rm <file>               Delete a note to the trash, listing notes that link to it\n")
+		fmt.Fprintf(os.Stderr, "  restore <file>          Restore a note from the vault's .trash folder\n")
+		fmt.Fprintf(os.Stderr, "  edit <file> --under HEADING|--replace-section HEADING|--prepend|--block ID [text]\n")
+		fmt.Fprintf(os.Stderr, "                          Edit part of a note, or find and replace with --find/--replace\n")
+		fmt.Fprintf(os.Stderr, "  props <action> <file> <key> [value]\n")
+		fmt.Fprintf(os.Stderr, "                          Get, set, unset, add to or remove from a property; props list shows all properties\n")
+		fmt.Fprintf(os.Stderr, "  migrate <spec.yaml>     Preview a property migration across the vault, or write it with --apply\n")
+		fmt.Fprintf(os.Stderr, "  history                 List recent changes made to the vault\n")
+		fmt.Fprintf(os.Stderr, "  undo <id>|--last N      Revert recorded changes, unless the notes have changed since\n")
+		fmt.Fprintf(os.Stderr, "  changes --since <rev>   Summarize the git commits agents made since a revision\n")
+		fmt.Fprintf(os.Stderr, "  periodic <action> <period> [date|text]\n")
+		fmt.Fprintf(os.Stderr, "                          Read, append to or create a daily/weekly/monthly/quarterly/yearly note\n")
+		fmt.Fprintf(os.Stderr, "  server                  Run the MCP server on stdio\n")
 	}
 	flag.Parse()
 
@@ -51,6 +79,17 @@ func main() {
 		fatal(*jsonOutput, "Invalid vault path: %v", err)
 	}
 
+	if gitConfig.Mode, err = vault.ParseGitMode(string(gitConfig.Mode)); err != nil {
+		fatal(*jsonOutput, "%v", err)
+	}
+
+	timeout := vault.DefaultLockTimeout
+	if *lockTimeout != "" {
+		if timeout, err = time.ParseDuration(*lockTimeout); err != nil || timeout < 0 {
+			fatal(*jsonOutput, "Invalid lock timeout %q, expected a duration such as 5s", *lockTimeout)
+		}
+	}
+
 	// 4. Handle Subcommands
 	args := flag.Args()
 	if len(args) == 0 {
@@ -63,8 +102,26 @@ func main() {
 
 	// Define a dependencies struct to pass around
 	deps := &commands.Dependencies{
-		VaultPath:  absVaultPath,
-		JsonOutput: *jsonOutput,
+		VaultPath:   absVaultPath,
+		JsonOutput:  *jsonOutput,
+		Version:     version,
+		Policy:      accessPolicy,
+		Command:     cmd,
+		DryRun:      *dryRun,
+		Git:         gitConfig,
+		LockTimeout: timeout,
+	}
+	// Other commands commit their changes together when they finish; the
+	// server commits per operation or session itself, but a vault outside
+	// git is still reported before anything is written
+	if gitConfig.Enabled() && !*dryRun {
+		committer, err := vault.NewGitCommitter(absVaultPath, vault.GitConfig{Mode: vault.GitPerSession, Author: gitConfig.Author})
+		if err != nil {
+			fatal(*jsonOutput, "%v", err)
+		}
+		if cmd != "server" {
+			deps.Committer = committer
+		}
 	}
 
 	var cmdErr error
@@ -94,11 +151,47 @@ func main() {
 		cmdErr = commands.RunIndex(deps, cmdArgs)
 	case "append":
 		cmdErr = commands.RunAppend(deps, cmdArgs)
+	case "mv", "move":
+		cmdErr = commands.RunMove(deps, cmdArgs)
+	case "rm", "delete":
+		cmdErr = commands.RunRemove(deps, cmdArgs)
+	case "restore":
+		cmdErr = commands.RunRestore(deps, cmdArgs)
+	case "edit":
+		cmdErr = commands.RunEdit(deps, cmdArgs)
+	case "props", "properties":
+		cmdErr = commands.RunProps(deps, cmdArgs)
+	case "migrate":
+		cmdErr = commands.RunMigrate(deps, cmdArgs)
+	case "history":
+		cmdErr = commands.RunHistory(deps, cmdArgs)
+	case "undo":
+		cmdErr = commands.RunUndo(deps, cmdArgs)
+	case "changes":
+		cmdErr = commands.RunChanges(deps, cmdArgs)
+	case "periodic":
+		cmdErr = commands.RunPeriodic(deps, cmdArgs)
+	case "server":
+		cmdErr = commands.RunServer(deps, cmdArgs)
 	default:
 		fatal(*jsonOutput, "Unknown command: %s", cmd)
 	}
 
+	// Changes made before a failure are committed too
+	if deps.Committer != nil {
+		if err := deps.Committer.Flush(); err != nil && cmdErr == nil {
+			cmdErr = fmt.Errorf("changes were saved but not committed: %w", err)
+		}
+	}
+
 	if cmdErr != nil {
+		// Policy and path errors carry structured details for JSON consumers
+		var detailed interface{ Details() map[string]interface{} }
+		if *jsonOutput && errors.As(cmdErr, &detailed) {
+			enc := json.NewEncoder(os.Stdout)
+			enc.Encode(map[string]interface{}{"error": cmdErr.Error(), "details": detailed.Details()})
+			os.Exit(1)
+		}
 		fatal(*jsonOutput, "%v", cmdErr)
 	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/cmd/obsidian-cli/commands"
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+
+	"github.com/joho/godotenv"
+)
+
+// version is set at build time by goreleaser
+var version = "dev"
+
+func main() {
+	loadEnv()
+
+	vaultPath := flag.String("vault", os.Getenv("OBSIDIAN_VAULT_PATH"), "Path to Obsidian vault")
+	accessPolicy := policy.FromEnv()
+	accessPolicy.RegisterFlags(flag.CommandLine)
+	gitConfig := vault.GitConfigFromEnv()
+	gitConfig.RegisterFlags(flag.CommandLine)
+	lockTimeout := flag.String("lock-timeout", os.Getenv("OBSIDIAN_LOCK_TIMEOUT"), "How long to wait for a note another process is changing (default 5s)")
+	host := flag.String("host", os.Getenv("MCP_HTTP_HOST"), "Interface to listen on (default 127.0.0.1)")
+	port := flag.String("port", os.Getenv("MCP_HTTP_PORT"), "Port to listen on (default 8080)")
+	path := flag.String("path", "/mcp", "Endpoint path")
+	noWatch := flag.Bool("no-watch", false, "Don't watch the vault for resource change notifications")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: obsidian-mcp-http [flags]\n")
+		fmt.Fprintf(os.Stderr, "\nServes the vault over the MCP Streamable HTTP transport.\n")
+		fmt.Fprintf(os.Stderr, "Set MCP_HTTP_TOKEN to require a bearer token.\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *vaultPath == "" {
+		wd, _ := os.Getwd()
+		*vaultPath = wd
+	}
+	absVaultPath, err := filepath.Abs(*vaultPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: Invalid vault path:", err)
+		os.Exit(1)
+	}
+
+	if gitConfig.Mode, err = vault.ParseGitMode(string(gitConfig.Mode)); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	// Report a vault outside git before serving anything
+	if gitConfig.Enabled() {
+		if _, err := vault.NewGitCommitter(absVaultPath, gitConfig); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	timeout := vault.DefaultLockTimeout
+	if *lockTimeout != "" {
+		if timeout, err = time.ParseDuration(*lockTimeout); err != nil || timeout < 0 {
+			fmt.Fprintf(os.Stderr, "Error: Invalid lock timeout %q, expected a duration such as 5s\n", *lockTimeout)
+			os.Exit(1)
+		}
+	}
+
+	deps := &commands.Dependencies{
+		VaultPath:   absVaultPath,
+		Version:     version,
+		Policy:      accessPolicy,
+		Git:         gitConfig,
+		LockTimeout: timeout,
+	}
+	serverArgs := []string{"--path", *path}
+	if *host != "" {
+		serverArgs = append(serverArgs, "--host", *host)
+	}
+	if *port != "" {
+		serverArgs = append(serverArgs, "--port", *port)
+	}
+	if *noWatch {
+		serverArgs = append(serverArgs, "--no-watch")
+	}
+
+	if err := commands.RunServerHTTP(deps, serverArgs); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func loadEnv() {
+	// Try loading .env from executable dir first, then CWD
+	execPath, err := os.Executable()
+	if err == nil {
+		execDir := filepath.Dir(execPath)
+		godotenv.Load(filepath.Join(execDir, ".env"))
+	}
+	godotenv.Load() // Fallback to CWD
+}
@@ -0,0 +1,184 @@
+// Package diff produces unified diffs of notes for previews
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change
+const context = 3
+
+// op is one line of an edit script: kept, deleted from a or inserted from b
+type op struct {
+	kind byte // ' ', '-' or '+'
+	a, b int  // line positions in a and b
+}
+
+// Unified returns the unified diff turning a into b, labelled with the
+// given file names, or "" if they are equal
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops) {
+		hunk := ops[h[0]:h[1]]
+		aStart, aCount, bStart, bCount := hunk[0].a, 0, hunk[0].b, 0
+		for _, o := range hunk {
+			if o.kind != '+' {
+				aCount++
+			}
+			if o.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, o := range hunk {
+			line := ""
+			if o.kind == '+' {
+				line = bLines[o.b]
+			} else {
+				line = aLines[o.a]
+			}
+			sb.WriteByte(o.kind)
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines that keep their line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange formats a hunk's start and length; empty ranges start at the
+// line before them
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// hunks groups the edit script into [start, end) ranges of changes with
+// their surrounding context
+func hunks(ops []op) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		// Extend the hunk while the next change is close enough to share context
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := min(end+context, len(ops))
+		if n := len(result); n > 0 && result[n-1][1] >= start {
+			result[n-1][1] = stop
+		} else {
+			result = append(result, [2]int{start, stop})
+		}
+		i = end
+	}
+	return result
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved frontiers back from the end to build the script
+func backtrack(trace [][]int, offset, x, y int) []op {
+	var ops []op
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{' ', x, y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{'+', x, y})
+		} else {
+			x--
+			ops = append(ops, op{'-', x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{' ', x, y})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
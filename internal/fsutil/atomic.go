@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to a file atomically: the data goes to a temporary
+// file in the same directory, is synced to disk, and then renamed over the
+// target, so readers and crashes see either the old or the new content and
+// never a truncated file. An existing file keeps its mode and ownership;
+// new files are created with perm. Symlinks are followed, so the link
+// itself is preserved and its target is replaced.
+func WriteFile(name string, data []byte, perm os.FileMode) error {
+	target, err := resolveTarget(name)
+	if err != nil {
+		return err
+	}
+
+	mode := perm
+	info, err := os.Stat(target)
+	if err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if info != nil {
+		if err := copyOwner(tmp, info); err != nil {
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return err
+	}
+	committed = true
+
+	// Persist the rename itself
+	return syncDir(dir)
+}
+
+// resolveTarget follows symlinks so the rename replaces the file they point
+// to rather than the link. Paths that don't exist yet are returned as-is.
+func resolveTarget(name string) (string, error) {
+	info, err := os.Lstat(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return name, nil
+		}
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return name, nil
+	}
+	target, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve symlink: %w", err)
+	}
+	return target, nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+// copyOwner is a no-op where files have no Unix ownership
+func copyOwner(f *os.File, info os.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op where directories can't be synced; Windows renames
+// are made durable by the file system itself
+func syncDir(dir string) error {
+	return nil
+}
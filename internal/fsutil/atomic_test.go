@@ -0,0 +1,91 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// tempFiles lists the temporary files WriteFile left in dir
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(name, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(name, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("got %q, %v, want new", data, err)
+	}
+	if info, err := os.Stat(name); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0600) {
+		t.Errorf("mode changed to %v, %v, want 0600 kept", info.Mode(), err)
+	}
+	if tmp := tempFiles(t, dir); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestWriteFileFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.md")
+	link := filepath.Join(dir, "link.md")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := WriteFile(link, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("target is %q, want new", data)
+	}
+}
+
+func TestWriteFileFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	// A folder can't be replaced by a file, so the final rename fails
+	name := filepath.Join(dir, "note.md")
+	if err := os.MkdirAll(filepath.Join(name, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(name, []byte("new"), 0644); err == nil {
+		t.Fatal("got no error replacing a folder")
+	}
+	if info, err := os.Stat(filepath.Join(name, "child")); err != nil || !info.IsDir() {
+		t.Errorf("original was changed: %v", err)
+	}
+	if tmp := tempFiles(t, dir); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	// Nothing is created when the folder is missing
+	missing := filepath.Join(dir, "missing", "note.md")
+	if err := WriteFile(missing, []byte("new"), 0644); err == nil {
+		t.Error("got no error writing into a missing folder")
+	}
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives f the owner and group of the file described by info.
+// Lacking permission to change the owner (when not running as root) is not
+// an error as long as the owner already matches.
+func copyOwner(f *os.File, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if err := f.Chown(int(stat.Uid), int(stat.Gid)); err != nil {
+		if os.IsPermission(err) && stat.Uid == uint32(os.Getuid()) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// syncDir flushes a directory's entries to disk so a rename survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
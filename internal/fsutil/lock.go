@@ -0,0 +1,41 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned by TryLock when another holder has the lock
+var ErrLocked = errors.New("file is locked")
+
+// FileLock is an advisory lock held on a lock file. Locks are tied to the
+// open file, so they conflict within a process too and are released if
+// the process dies.
+type FileLock struct {
+	f *os.File
+}
+
+// TryLock locks the file at path, creating it if needed, without waiting.
+// Shared locks may be held together; an exclusive lock excludes all others.
+// It returns ErrLocked if the lock is taken.
+func TryLock(path string, shared bool) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, shared); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock. The lock file is left in place, since removing
+// it would let another process lock a different file under the same name.
+func (l *FileLock) Unlock() error {
+	err := unlockFile(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// MoveToTrash moves a file to the user's Trash and returns its new path
+func MoveToTrash(path string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dst := UniquePath(filepath.Join(home, ".Trash", filepath.Base(path)))
+	if err := MoveFile(path, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package fsutil
+
+// MoveToTrash is unsupported here; callers fall back to another trash
+func MoveToTrash(path string) (string, error) {
+	return "", ErrTrashUnsupported
+}
@@ -0,0 +1,76 @@
+//go:build unix && !darwin
+
+package fsutil
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MoveToTrash moves a file to the user's trash following the freedesktop.org
+// Trash specification, so file managers can restore it, and returns its new path
+func MoveToTrash(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	trashDir, err := homeTrash()
+	if err != nil {
+		return "", err
+	}
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return "", err
+	}
+
+	// The .trashinfo file is created exclusively to claim a name
+	ext := filepath.Ext(abs)
+	base := strings.TrimSuffix(filepath.Base(abs), ext)
+	for i := 1; ; i++ {
+		name := base + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d%s", base, i, ext)
+		}
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		info, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		escaped := (&url.URL{Path: abs}).EscapedPath()
+		_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, time.Now().Format("2006-01-02T15:04:05"))
+		if closeErr := info.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			dst := filepath.Join(filesDir, name)
+			if err = MoveFile(abs, dst); err == nil {
+				return dst, nil
+			}
+		}
+		os.Remove(infoPath)
+		return "", err
+	}
+}
+
+// homeTrash returns the user's home trash directory
+func homeTrash() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
@@ -2,13 +2,10 @@ package gardener
 
 import (
 	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
-)
 
-// wikilinkRegex matches [[wikilinks]] including aliased links [[target|alias]]
-var wikilinkRegex = regexp.MustCompile(`\[\[([^\]|]+)(?:\|[^\]]+)?\]\]`)
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
 
 // LinkGraph represents the link structure of the vault
 type LinkGraph struct {
@@ -25,14 +22,23 @@ type LinkGraph struct {
 // OrphanFinder identifies orphan notes in the vault
 type OrphanFinder struct {
 	vaultPath string
+	paths     *vault.PathResolver
+	policy    *policy.Policy
 }
 
 // NewOrphanFinder creates a new OrphanFinder
 func NewOrphanFinder(vaultPath string) *OrphanFinder {
-	return &OrphanFinder{vaultPath: vaultPath}
+	return &OrphanFinder{vaultPath: vaultPath, paths: vault.NewPathResolver(vaultPath)}
+}
+
+// SetPolicy hides notes the policy doesn't allow reading (nil allows all)
+func (o *OrphanFinder) SetPolicy(p *policy.Policy) {
+	o.policy = p
 }
 
-// BuildLinkGraph scans the vault and builds a link graph
+// BuildLinkGraph scans the vault and builds a link graph. Links are
+// resolved the way Obsidian resolves them: by path, basename or alias, and
+// markdown links relative to the note or the vault root.
 func (o *OrphanFinder) BuildLinkGraph() (*LinkGraph, error) {
 	graph := &LinkGraph{
 		Outgoing: make(map[string][]string),
@@ -40,52 +46,35 @@ func (o *OrphanFinder) BuildLinkGraph() (*LinkGraph, error) {
 	}
 
 	// First pass: collect all notes
-	err := filepath.Walk(o.vaultPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip hidden files and directories
-		if strings.HasPrefix(info.Name(), ".") {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Only process markdown files
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".md") {
-			relPath, _ := filepath.Rel(o.vaultPath, path)
-			graph.AllNotes = append(graph.AllNotes, relPath)
-			graph.Outgoing[relPath] = []string{}
-		}
-
-		return nil
-	})
-
+	resolver := vault.NewNoteResolver(o.vaultPath)
+	resolver.SetPolicy(o.policy)
+	notes, err := resolver.Notes()
 	if err != nil {
 		return nil, err
 	}
+	for _, relPath := range notes {
+		graph.AllNotes = append(graph.AllNotes, relPath)
+		graph.Outgoing[relPath] = []string{}
+	}
 
 	// Second pass: extract links
 	for _, notePath := range graph.AllNotes {
-		fullPath := filepath.Join(o.vaultPath, notePath)
+		fullPath, _, err := o.paths.Resolve(notePath)
+		if err != nil {
+			continue
+		}
 		content, err := os.ReadFile(fullPath)
 		if err != nil {
 			continue // Skip files we can't read
 		}
 
-		// Find all wikilinks
-		matches := wikilinkRegex.FindAllStringSubmatch(string(content), -1)
-		for _, match := range matches {
-			if len(match) < 2 {
+		// Links in code are not links, but embeds and properties are
+		for _, link := range vault.ParseDocument(string(content)).Links {
+			targetPath, ok := resolver.ResolveLink(link, notePath)
+			if !ok {
 				continue
 			}
 
-			target := match[1]
-			// Normalize the target (add .md if needed, resolve relative paths)
-			targetPath := normalizeLink(target, notePath)
-
 			// Check if target exists in our graph
 			if _, exists := graph.Outgoing[targetPath]; exists {
 				graph.Outgoing[notePath] = append(graph.Outgoing[notePath], targetPath)
@@ -101,19 +90,6 @@ func (o *OrphanFinder) BuildLinkGraph() (*LinkGraph, error) {
 	return graph, nil
 }
 
-// normalizeLink converts a wikilink target to a file path
-func normalizeLink(target, sourcePath string) string {
-	// Remove any leading/trailing whitespace
-	target = strings.TrimSpace(target)
-
-	// Add .md extension if not present
-	if !strings.HasSuffix(target, ".md") {
-		target = target + ".md"
-	}
-
-	return target
-}
-
 // FindOrphans returns notes with no incoming or outgoing links
 func (o *OrphanFinder) FindOrphans() ([]string, error) {
 	graph, err := o.BuildLinkGraph()
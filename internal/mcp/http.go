@@ -0,0 +1,367 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionHeader carries the session ID on every request after initialize
+const SessionHeader = "Mcp-Session-Id"
+
+// maxRequestBody caps the size of a single POSTed JSON-RPC payload
+const maxRequestBody = 4 << 20
+
+// HTTPConfig holds configuration for the Streamable HTTP transport
+type HTTPConfig struct {
+	// AuthToken, when set, is required as "Authorization: Bearer <token>"
+	AuthToken string
+
+	// AllowedOrigins lists browser origins permitted to connect. Requests
+	// without an Origin header are always allowed; localhost origins are
+	// allowed by default to guard against DNS rebinding.
+	AllowedOrigins []string
+
+	// SessionTimeout expires sessions idle for longer than this
+	// Default: 30 minutes
+	SessionTimeout time.Duration
+
+	// KeepAlive is the interval between SSE keep-alive comments
+	// Default: 25 seconds
+	KeepAlive time.Duration
+}
+
+// HTTPHandler serves the MCP Streamable HTTP transport on a single endpoint:
+// POST carries client messages, GET opens an SSE stream for server-initiated
+// messages, and DELETE ends the session.
+type HTTPHandler struct {
+	server *Server
+	config HTTPConfig
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession pairs a protocol session with its SSE outbox
+type httpSession struct {
+	id       string
+	session  *Session
+	outbox   chan []byte
+	lastSeen time.Time
+
+	streamMu sync.Mutex
+	streamOn bool
+}
+
+// NewHTTPHandler creates a Streamable HTTP handler for the server
+func (s *Server) NewHTTPHandler(config HTTPConfig) *HTTPHandler {
+	if config.SessionTimeout == 0 {
+		config.SessionTimeout = 30 * time.Minute
+	}
+	if config.KeepAlive == 0 {
+		config.KeepAlive = 25 * time.Second
+	}
+	return &HTTPHandler{
+		server:   s,
+		config:   config,
+		sessions: make(map[string]*httpSession),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost processes one JSON-RPC message or batch from the client
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestBody {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var hs *httpSession
+	if isInitialize(body) {
+		hs, err = h.createSession()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			http.Error(w, "missing "+SessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		if hs = h.lookupSession(id); hs == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set(SessionHeader, hs.id)
+	resp := hs.session.Handle(r.Context(), body)
+	if resp == nil {
+		// Only notifications or responses were posted
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// handleStream opens the SSE stream used for server-to-client notifications
+func (h *HTTPHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
+	hs := h.lookupSession(r.Header.Get(SessionHeader))
+	if hs == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Only one stream per session, otherwise notifications would be split between them
+	hs.streamMu.Lock()
+	if hs.streamOn {
+		hs.streamMu.Unlock()
+		http.Error(w, "stream already open for session", http.StatusConflict)
+		return
+	}
+	hs.streamOn = true
+	hs.streamMu.Unlock()
+	defer func() {
+		hs.streamMu.Lock()
+		hs.streamOn = false
+		hs.streamMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(SessionHeader, hs.id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.config.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data, ok := <-hs.outbox:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			h.touch(hs)
+
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			h.touch(hs)
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleDelete terminates a session at the client's request
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(SessionHeader)
+
+	h.mu.Lock()
+	hs, ok := h.sessions[id]
+	if ok {
+		delete(h.sessions, id)
+	}
+	h.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	h.closeSession(hs)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createSession registers a new session and expires idle ones
+func (h *HTTPHandler) createSession() (*httpSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	hs := &httpSession{
+		id:       id,
+		outbox:   make(chan []byte, 64),
+		lastSeen: time.Now(),
+	}
+	hs.session = h.server.NewSession(func(data []byte) error {
+		select {
+		case hs.outbox <- data:
+			return nil
+		default:
+			// No stream is draining the outbox; drop rather than block the server
+			return fmt.Errorf("notification dropped: session %s outbox full", hs.id)
+		}
+	})
+
+	h.mu.Lock()
+	var expired []*httpSession
+	for sid, other := range h.sessions {
+		if time.Since(other.lastSeen) > h.config.SessionTimeout {
+			delete(h.sessions, sid)
+			expired = append(expired, other)
+		}
+	}
+	h.sessions[id] = hs
+	h.mu.Unlock()
+
+	for _, other := range expired {
+		log.Printf("MCP session %s expired", other.id)
+		h.closeSession(other)
+	}
+	return hs, nil
+}
+
+// lookupSession returns the live session for id, or nil
+func (h *HTTPHandler) lookupSession(id string) *httpSession {
+	if id == "" {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hs, ok := h.sessions[id]
+	if !ok {
+		return nil
+	}
+	hs.lastSeen = time.Now()
+	return hs
+}
+
+// touch records activity on a session
+func (h *HTTPHandler) touch(hs *httpSession) {
+	h.mu.Lock()
+	hs.lastSeen = time.Now()
+	h.mu.Unlock()
+}
+
+// closeSession closes the protocol session and ends its stream
+func (h *HTTPHandler) closeSession(hs *httpSession) {
+	hs.session.Close()
+	close(hs.outbox)
+}
+
+// Close terminates every open session
+func (h *HTTPHandler) Close() {
+	h.mu.Lock()
+	sessions := h.sessions
+	h.sessions = make(map[string]*httpSession)
+	h.mu.Unlock()
+
+	for _, hs := range sessions {
+		h.closeSession(hs)
+	}
+}
+
+// authorized checks the bearer token when one is configured
+func (h *HTTPHandler) authorized(r *http.Request) bool {
+	if h.config.AuthToken == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AuthToken)) == 1
+}
+
+// originAllowed validates the Origin header to prevent DNS rebinding attacks
+func (h *HTTPHandler) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isInitialize reports whether the payload is an initialize request
+func isInitialize(body []byte) bool {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return false
+	}
+	return msg.Method == "initialize"
+}
+
+// newSessionID returns a random, unguessable session identifier
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"encoding/json"
+)
+
+// Prompt describes a reusable prompt template
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument a prompt accepts
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a single message of a rendered prompt
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// PromptResult is the result of prompts/get
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptProvider supplies the prompts a server exposes
+type PromptProvider interface {
+	ListPrompts() ([]Prompt, error)
+	GetPrompt(name string, args map[string]string) (*PromptResult, error)
+}
+
+// InvalidPromptError reports an unknown prompt or missing or invalid arguments
+type InvalidPromptError struct {
+	Prompt  string
+	Message string
+}
+
+func (e *InvalidPromptError) Error() string {
+	return "prompt " + e.Prompt + ": " + e.Message
+}
+
+// SetPromptProvider enables the prompts capability backed by p
+func (s *Server) SetPromptProvider(p PromptProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = p
+}
+
+// promptProvider returns the configured provider, or nil
+func (s *Server) promptProvider() PromptProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prompts
+}
+
+// NotifyPromptListChanged tells every session that the prompt catalog changed
+func (s *Server) NotifyPromptListChanged() {
+	for _, sess := range s.liveSessions() {
+		sess.Notify("notifications/prompts/list_changed", nil)
+	}
+}
+
+type getPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments"`
+}
+
+// handleListPrompts returns the prompt catalog
+func (sess *Session) handleListPrompts() (interface{}, *RPCError) {
+	provider := sess.server.promptProvider()
+	if provider == nil {
+		return nil, newError(CodeMethodNotFound, "prompts are not supported")
+	}
+
+	prompts, err := provider.ListPrompts()
+	if err != nil {
+		return nil, newError(CodeInternalError, "failed to list prompts: %v", err)
+	}
+	if prompts == nil {
+		prompts = []Prompt{}
+	}
+	return map[string]interface{}{"prompts": prompts}, nil
+}
+
+// handleGetPrompt renders a prompt with the client's arguments
+func (sess *Session) handleGetPrompt(raw json.RawMessage) (interface{}, *RPCError) {
+	provider := sess.server.promptProvider()
+	if provider == nil {
+		return nil, newError(CodeMethodNotFound, "prompts are not supported")
+	}
+
+	var params getPromptParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.Name == "" {
+		return nil, newError(CodeInvalidParams, "prompts/get requires a name")
+	}
+
+	result, err := provider.GetPrompt(params.Name, params.Arguments)
+	if err != nil {
+		if _, ok := err.(*InvalidPromptError); ok {
+			return nil, newError(CodeInvalidParams, "%v", err)
+		}
+		return nil, newError(CodeInternalError, "%v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion is the newest MCP revision this server implements
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions lists every protocol revision we can speak, newest first
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is a single JSON-RPC 2.0 message (request, notification or response)
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// IsNotification reports whether the message expects no response
+func (m *Message) IsNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// RPCError is a JSON-RPC 2.0 error object
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// newError builds an RPCError with a formatted message
+func newError(code int, format string, args ...interface{}) *RPCError {
+	return &RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// newResponse builds a response message for the given request ID
+func newResponse(id json.RawMessage, result interface{}) *Message {
+	if result == nil {
+		result = struct{}{}
+	}
+	return &Message{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+// newErrorResponse builds an error response for the given request ID
+func newErrorResponse(id json.RawMessage, err *RPCError) *Message {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Message{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// Implementation identifies a client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// initializeParams is sent by the client to open a session
+type initializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+}
+
+// initializeResult is the server's answer to initialize
+type initializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      Implementation         `json:"serverInfo"`
+	Instructions    string                 `json:"instructions,omitempty"`
+}
+
+// callToolParams is the payload of tools/call
+type callToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Content is a single content item in a tool result
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of tools/call
+type ToolResult struct {
+	Content           []Content              `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
+}
+
+// TextResult wraps plain text in a ToolResult
+func TextResult(text string) *ToolResult {
+	return &ToolResult{Content: []Content{{Type: "text", Text: text}}}
+}
+
+// JSONResult encodes v as indented JSON text in a ToolResult
+func JSONResult(v interface{}) (*ToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return TextResult(string(data)), nil
+}
+
+// detailedError is implemented by errors that carry structured details,
+// such as policy.PermissionError
+type detailedError interface {
+	error
+	Details() map[string]interface{}
+}
+
+// ErrorResult reports a tool-level failure to the client. Errors with
+// structured details are also returned as structuredContent.
+func ErrorResult(err error) *ToolResult {
+	result := TextResult(err.Error())
+	result.IsError = true
+
+	var detailed detailedError
+	if errors.As(err, &detailed) {
+		result.StructuredContent = map[string]interface{}{"error": detailed.Details()}
+	}
+	return result
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// resourcePageSize is the number of resources returned per resources/list page
+const resourcePageSize = 200
+
+// Resource describes a readable resource
+type Resource struct {
+	URI         string                 `json:"uri"`
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	MimeType    string                 `json:"mimeType,omitempty"`
+	Size        int64                  `json:"size,omitempty"`
+	Meta        map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ResourceTemplate describes a parameterized family of resources (RFC 6570 URI template)
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the body of a resource returned by resources/read
+type ResourceContents struct {
+	URI      string                 `json:"uri"`
+	MimeType string                 `json:"mimeType,omitempty"`
+	Text     string                 `json:"text"`
+	Meta     map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ResourceProvider supplies the resources a server exposes
+type ResourceProvider interface {
+	ListResources() ([]Resource, error)
+	ListResourceTemplates() ([]ResourceTemplate, error)
+	ReadResource(uri string) (*ResourceContents, error)
+}
+
+// SetResourceProvider enables the resources capability backed by p
+func (s *Server) SetResourceProvider(p ResourceProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources = p
+}
+
+// resourceProvider returns the configured provider, or nil
+func (s *Server) resourceProvider() ResourceProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resources
+}
+
+// NotifyResourceUpdated tells every session subscribed to uri that it changed
+func (s *Server) NotifyResourceUpdated(uri string) {
+	for _, sess := range s.liveSessions() {
+		if sess.isSubscribed(uri) {
+			sess.Notify("notifications/resources/updated", map[string]string{"uri": uri})
+		}
+	}
+}
+
+// NotifyResourceListChanged tells every session that resources were added or removed
+func (s *Server) NotifyResourceListChanged() {
+	for _, sess := range s.liveSessions() {
+		sess.Notify("notifications/resources/list_changed", nil)
+	}
+}
+
+// isSubscribed reports whether the session subscribed to uri
+func (sess *Session) isSubscribed(uri string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.subscriptions[uri]
+}
+
+type listResourcesParams struct {
+	Cursor string `json:"cursor"`
+}
+
+type resourceURIParams struct {
+	URI string `json:"uri"`
+}
+
+// handleListResources returns one page of resources
+func (sess *Session) handleListResources(raw json.RawMessage) (interface{}, *RPCError) {
+	provider := sess.server.resourceProvider()
+	if provider == nil {
+		return nil, newError(CodeMethodNotFound, "resources are not supported")
+	}
+
+	var params listResourcesParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, newError(CodeInvalidParams, "invalid resources/list params: %v", err)
+		}
+	}
+
+	start := 0
+	if params.Cursor != "" {
+		n, err := strconv.Atoi(params.Cursor)
+		if err != nil || n < 0 {
+			return nil, newError(CodeInvalidParams, "invalid cursor: %s", params.Cursor)
+		}
+		start = n
+	}
+
+	resources, err := provider.ListResources()
+	if err != nil {
+		return nil, newError(CodeInternalError, "failed to list resources: %v", err)
+	}
+
+	result := map[string]interface{}{}
+	if start > len(resources) {
+		start = len(resources)
+	}
+	end := start + resourcePageSize
+	if end < len(resources) {
+		result["nextCursor"] = strconv.Itoa(end)
+	} else {
+		end = len(resources)
+	}
+	result["resources"] = resources[start:end]
+	return result, nil
+}
+
+// handleListResourceTemplates returns every resource template
+func (sess *Session) handleListResourceTemplates() (interface{}, *RPCError) {
+	provider := sess.server.resourceProvider()
+	if provider == nil {
+		return nil, newError(CodeMethodNotFound, "resources are not supported")
+	}
+
+	templates, err := provider.ListResourceTemplates()
+	if err != nil {
+		return nil, newError(CodeInternalError, "failed to list resource templates: %v", err)
+	}
+	if templates == nil {
+		templates = []ResourceTemplate{}
+	}
+	return map[string]interface{}{"resourceTemplates": templates}, nil
+}
+
+// handleReadResource returns the contents of one resource
+func (sess *Session) handleReadResource(raw json.RawMessage) (interface{}, *RPCError) {
+	provider := sess.server.resourceProvider()
+	if provider == nil {
+		return nil, newError(CodeMethodNotFound, "resources are not supported")
+	}
+
+	var params resourceURIParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.URI == "" {
+		return nil, newError(CodeInvalidParams, "resources/read requires a uri")
+	}
+
+	contents, err := provider.ReadResource(params.URI)
+	if err != nil {
+		// -32002 is the MCP code for "resource not found"
+		return nil, &RPCError{Code: -32002, Message: err.Error(), Data: map[string]string{"uri": params.URI}}
+	}
+	return map[string]interface{}{"contents": []*ResourceContents{contents}}, nil
+}
+
+// handleSubscribe records or removes interest in updates to a resource
+func (sess *Session) handleSubscribe(raw json.RawMessage, subscribe bool) (interface{}, *RPCError) {
+	if sess.server.resourceProvider() == nil {
+		return nil, newError(CodeMethodNotFound, "resources are not supported")
+	}
+
+	var params resourceURIParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.URI == "" {
+		return nil, newError(CodeInvalidParams, "subscription requires a uri")
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if subscribe {
+		if sess.subscriptions == nil {
+			sess.subscriptions = make(map[string]bool)
+		}
+		sess.subscriptions[params.URI] = true
+	} else {
+		delete(sess.subscriptions, params.URI)
+	}
+	return struct{}{}, nil
+}
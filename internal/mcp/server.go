@@ -0,0 +1,379 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Server is a Model Context Protocol server holding the tool registry.
+// Transports create one Session per connected client.
+type Server struct {
+	info         Implementation
+	instructions string
+
+	mu        sync.RWMutex
+	tools     map[string]*Tool
+	toolOrder []string
+	resources ResourceProvider
+	prompts   PromptProvider
+	filter    func(*Tool) error
+	sessions  map[*Session]struct{}
+	onClose   []func(*Session)
+}
+
+// NewServer creates an empty server with the given implementation info
+func NewServer(name, version string) *Server {
+	return &Server{
+		info:     Implementation{Name: name, Version: version},
+		tools:    make(map[string]*Tool),
+		sessions: make(map[*Session]struct{}),
+	}
+}
+
+// SetInstructions sets the usage hint returned to clients on initialize
+func (s *Server) SetInstructions(instructions string) {
+	s.instructions = instructions
+}
+
+// AddTool registers a tool, replacing any existing tool with the same name
+func (s *Server) AddTool(tool *Tool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tool.InputSchema == nil {
+		tool.InputSchema = ObjectSchema(nil)
+	}
+	if _, exists := s.tools[tool.Name]; !exists {
+		s.toolOrder = append(s.toolOrder, tool.Name)
+	}
+	s.tools[tool.Name] = tool
+}
+
+// SetToolFilter installs a permission check for tools. Tools the filter
+// rejects are hidden from tools/list and fail with its error when called.
+func (s *Server) SetToolFilter(filter func(*Tool) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = filter
+}
+
+// Tools returns the permitted tools in registration order
+func (s *Server) Tools() []*Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tools := make([]*Tool, 0, len(s.toolOrder))
+	for _, name := range s.toolOrder {
+		tool := s.tools[name]
+		if s.filter != nil && s.filter(tool) != nil {
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// checkTool applies the tool filter, if any
+func (s *Server) checkTool(tool *Tool) error {
+	s.mu.RLock()
+	filter := s.filter
+	s.mu.RUnlock()
+
+	if filter == nil {
+		return nil
+	}
+	return filter(tool)
+}
+
+// OnSessionClose registers a function called when a session ends
+func (s *Server) OnSessionClose(fn func(*Session)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onClose = append(s.onClose, fn)
+}
+
+// lookupTool returns the tool registered under name
+func (s *Server) lookupTool(name string) (*Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.tools[name]
+	return tool, ok
+}
+
+// liveSessions returns a snapshot of all open sessions
+func (s *Server) liveSessions() []*Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// Session is the protocol state of a single connected client
+type Session struct {
+	server *Server
+	send   func([]byte) error
+
+	mu            sync.Mutex
+	initialized   bool
+	closed        bool
+	client        Implementation
+	subscriptions map[string]bool
+}
+
+// NewSession starts a new client session. send delivers server-initiated
+// messages (notifications) to the client; it may be nil if the transport
+// has no channel for them.
+func (s *Server) NewSession(send func([]byte) error) *Session {
+	sess := &Session{server: s, send: send}
+
+	s.mu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	return sess
+}
+
+// Close ends the session and stops delivery of notifications to it
+func (sess *Session) Close() {
+	sess.mu.Lock()
+	wasClosed := sess.closed
+	sess.closed = true
+	sess.mu.Unlock()
+
+	sess.server.mu.Lock()
+	delete(sess.server.sessions, sess)
+	onClose := sess.server.onClose
+	sess.server.mu.Unlock()
+
+	if !wasClosed {
+		for _, fn := range onClose {
+			fn(sess)
+		}
+	}
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the session whose request is being handled, or nil
+func SessionFromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionKey{}).(*Session)
+	return sess
+}
+
+// Notify sends a notification to the client. Notifications are dropped
+// until the client has completed initialization.
+func (sess *Session) Notify(method string, params interface{}) error {
+	msg := &Message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification params: %w", err)
+		}
+		msg.Params = data
+	}
+
+	// Hold the lock while sending so Close never races a send in flight
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.initialized || sess.closed || sess.send == nil {
+		return nil
+	}
+	return sess.send(encode(msg))
+}
+
+// Handle processes one raw JSON-RPC payload (a single message or a batch)
+// and returns the encoded response, or nil if nothing needs to be sent back.
+func (sess *Session) Handle(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	// Batches are arrays of messages answered by an array of responses
+	if data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return encode(newErrorResponse(nil, newError(CodeParseError, "parse error: %v", err)))
+		}
+		if len(batch) == 0 {
+			return encode(newErrorResponse(nil, newError(CodeInvalidRequest, "empty batch")))
+		}
+
+		var responses []*Message
+		for _, raw := range batch {
+			if resp := sess.handleRaw(ctx, raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return encode(responses)
+	}
+
+	if resp := sess.handleRaw(ctx, data); resp != nil {
+		return encode(resp)
+	}
+	return nil
+}
+
+// handleRaw decodes and dispatches a single message
+func (sess *Session) handleRaw(ctx context.Context, raw json.RawMessage) *Message {
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return newErrorResponse(nil, newError(CodeParseError, "parse error: %v", err))
+	}
+	if msg.JSONRPC != "2.0" || msg.Method == "" {
+		// Responses from the client carry no method; we never send requests, so drop them
+		if msg.Method == "" && (msg.Result != nil || msg.Error != nil) {
+			return nil
+		}
+		return newErrorResponse(msg.ID, newError(CodeInvalidRequest, "invalid request"))
+	}
+
+	result, rpcErr := sess.dispatch(ctx, &msg)
+	if msg.IsNotification() {
+		return nil
+	}
+	if rpcErr != nil {
+		return newErrorResponse(msg.ID, rpcErr)
+	}
+	return newResponse(msg.ID, result)
+}
+
+// dispatch routes a method call to its handler
+func (sess *Session) dispatch(ctx context.Context, msg *Message) (interface{}, *RPCError) {
+	switch msg.Method {
+	case "initialize":
+		return sess.handleInitialize(msg.Params)
+	case "notifications/initialized":
+		sess.mu.Lock()
+		sess.initialized = true
+		sess.mu.Unlock()
+		return nil, nil
+	case "notifications/cancelled":
+		return nil, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": sess.server.Tools()}, nil
+	case "tools/call":
+		return sess.handleCallTool(ctx, msg.Params)
+	case "prompts/list":
+		return sess.handleListPrompts()
+	case "prompts/get":
+		return sess.handleGetPrompt(msg.Params)
+	case "resources/list":
+		return sess.handleListResources(msg.Params)
+	case "resources/templates/list":
+		return sess.handleListResourceTemplates()
+	case "resources/read":
+		return sess.handleReadResource(msg.Params)
+	case "resources/subscribe":
+		return sess.handleSubscribe(msg.Params, true)
+	case "resources/unsubscribe":
+		return sess.handleSubscribe(msg.Params, false)
+	default:
+		return nil, newError(CodeMethodNotFound, "method not found: %s", msg.Method)
+	}
+}
+
+// handleInitialize negotiates the protocol version and advertises capabilities
+func (sess *Session) handleInitialize(raw json.RawMessage) (interface{}, *RPCError) {
+	var params initializeParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, newError(CodeInvalidParams, "invalid initialize params: %v", err)
+		}
+	}
+
+	// Echo the client's version if we support it, otherwise offer our newest
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == params.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	sess.mu.Lock()
+	sess.client = params.ClientInfo
+	sess.mu.Unlock()
+
+	capabilities := map[string]interface{}{
+		"tools": map[string]interface{}{"listChanged": false},
+	}
+	if sess.server.resourceProvider() != nil {
+		capabilities["resources"] = map[string]interface{}{"subscribe": true, "listChanged": true}
+	}
+	if sess.server.promptProvider() != nil {
+		capabilities["prompts"] = map[string]interface{}{"listChanged": true}
+	}
+
+	return &initializeResult{
+		ProtocolVersion: version,
+		Capabilities:    capabilities,
+		ServerInfo:      sess.server.info,
+		Instructions:    sess.server.instructions,
+	}, nil
+}
+
+// handleCallTool runs a registered tool. Tool failures are reported inside
+// the result with isError set so the model can see and react to them.
+func (sess *Session) handleCallTool(ctx context.Context, raw json.RawMessage) (interface{}, *RPCError) {
+	var params callToolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, newError(CodeInvalidParams, "invalid tools/call params: %v", err)
+	}
+
+	tool, ok := sess.server.lookupTool(params.Name)
+	if !ok {
+		return nil, newError(CodeInvalidParams, "unknown tool: %s", params.Name)
+	}
+
+	if err := sess.server.checkTool(tool); err != nil {
+		return ErrorResult(err), nil
+	}
+
+	args := Arguments(params.Arguments)
+	if args == nil {
+		args = Arguments{}
+	}
+
+	result, err := runTool(context.WithValue(ctx, sessionKey{}, sess), tool, args)
+	if err != nil {
+		return ErrorResult(err), nil
+	}
+	return result, nil
+}
+
+// runTool calls the tool handler, converting panics into errors
+func runTool(ctx context.Context, tool *Tool, args Arguments) (result *ToolResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("tool %s panicked: %v", tool.Name, r)
+		}
+	}()
+
+	result, err = tool.Handler(context.WithValue(ctx, toolNameKey{}, tool.Name), args)
+	if err == nil && result == nil {
+		result = TextResult("")
+	}
+	return result, err
+}
+
+// encode marshals a response, falling back to an internal error
+func encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(newErrorResponse(nil, newError(CodeInternalError, "failed to encode response: %v", err)))
+	}
+	return data
+}
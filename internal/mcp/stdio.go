@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ServeStdio runs a single session over newline-delimited JSON-RPC on the
+// given streams (normally stdin/stdout). It returns when in reaches EOF or
+// ctx is cancelled. Nothing but protocol messages may be written to out.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	var writeMu sync.Mutex
+	write := func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write message: %w", err)
+		}
+		return nil
+	}
+
+	session := s.NewSession(write)
+	defer session.Close()
+
+	reader := bufio.NewReaderSize(in, 64*1024)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if resp := session.Handle(ctx, line); resp != nil {
+				if werr := write(resp); werr != nil {
+					return werr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// ToolHandler executes a tool call with decoded arguments
+type ToolHandler func(ctx context.Context, args Arguments) (*ToolResult, error)
+
+type toolNameKey struct{}
+
+// ToolName returns the name of the tool whose handler is running, or ""
+func ToolName(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey{}).(string)
+	return name
+}
+
+// Tool describes a callable tool and its JSON Schema input definition
+type Tool struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	InputSchema *Schema          `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+	Handler     ToolHandler      `json:"-"`
+}
+
+// ToolAnnotations are behavioural hints for clients
+type ToolAnnotations struct {
+	// ReadOnlyHint marks tools that never modify the vault
+	ReadOnlyHint bool `json:"readOnlyHint,omitempty"`
+}
+
+// IsReadOnly reports whether the tool is annotated as read-only
+func (t *Tool) IsReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint
+}
+
+// readOnly is the annotation shared by every read-only tool
+var readOnly = &ToolAnnotations{ReadOnlyHint: true}
+
+// Schema is the subset of JSON Schema used to describe tool inputs
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// ObjectSchema builds an object schema from properties and required keys
+func ObjectSchema(properties map[string]*Schema, required ...string) *Schema {
+	if properties == nil {
+		properties = map[string]*Schema{}
+	}
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// StringProp describes a string property
+func StringProp(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+// IntegerProp describes an integer property
+func IntegerProp(description string) *Schema {
+	return &Schema{Type: "integer", Description: description}
+}
+
+// BooleanProp describes a boolean property
+func BooleanProp(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+// Arguments holds the decoded arguments of a tool call
+type Arguments map[string]interface{}
+
+// String returns the string argument for key, or "" if missing
+func (a Arguments) String(key string) string {
+	if v, ok := a[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// RequireString returns the string argument for key or an error if it is missing or empty
+func (a Arguments) RequireString(key string) (string, error) {
+	v, ok := a[key]
+	if !ok || v == nil {
+		return "", fmt.Errorf("missing required argument %q", key)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", key)
+	}
+	if s == "" {
+		return "", fmt.Errorf("argument %q must not be empty", key)
+	}
+	return s, nil
+}
+
+// Int returns the integer argument for key, or def if missing
+func (a Arguments) Int(key string, def int) (int, error) {
+	v, ok := a[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f != math.Trunc(f) {
+		return 0, fmt.Errorf("argument %q must be an integer", key)
+	}
+	return int(f), nil
+}
+
+// Bool returns the boolean argument for key, or false if missing
+func (a Arguments) Bool(key string) bool {
+	b, _ := a[key].(bool)
+	return b
+}
+
+// Object returns the object argument for key, or nil if missing
+func (a Arguments) Object(key string) (map[string]interface{}, error) {
+	v, ok := a[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argument %q must be an object", key)
+	}
+	return m, nil
+}
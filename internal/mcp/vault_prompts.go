@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+// DefaultPromptsFolder is the vault folder prompts are loaded from
+const DefaultPromptsFolder = "Prompts"
+
+// promptVarRegex matches {{name}} and {{func:arg}} placeholders in prompt bodies
+var promptVarRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)(?::([^}]*))?\s*\}\}`)
+
+// builtinPrompts ship with the server. A note in the prompts folder with the
+// same name overrides the builtin, so they double as examples of the format.
+var builtinPrompts = map[string]string{
+	"summarize-note": `---
+title: Summarize note
+description: Summarize a note into key points and open questions
+arguments:
+  - name: note
+    description: Path of the note to summarize
+    required: true
+---
+Summarize the note below. List the key points as bullets, then any open questions or follow-ups.
+
+Note: {{note}}
+
+{{note:note}}
+`,
+	"weekly-review": `---
+title: Weekly review
+description: Run a weekly review over the last seven daily notes
+arguments:
+  - name: focus
+    description: Optional area to focus the review on
+---
+Here are my daily notes from the past week. Write a weekly review with sections for wins, challenges, lessons learned and priorities for next week. {{focus}}
+
+{{daily_notes:7}}
+`,
+	"draft-from-daily-notes": `---
+title: Draft from daily notes
+description: Draft a new note on a topic from recent daily notes
+arguments:
+  - name: topic
+    description: What the draft should be about
+    required: true
+  - name: days
+    description: How many days of daily notes to draw from
+    default: "7"
+---
+Using only the daily notes below, draft a well-structured note about "{{topic}}". Link related ideas with [[wikilinks]] where the notes mention them.
+
+{{daily_notes:days}}
+`,
+}
+
+// promptArgumentDef is an argument declared in a prompt note's frontmatter
+type promptArgumentDef struct {
+	PromptArgument
+	Default string
+}
+
+// promptDef is a parsed prompt note
+type promptDef struct {
+	Prompt
+	args []promptArgumentDef
+	body string
+}
+
+// vaultPrompts loads prompts from markdown notes in a vault folder
+type vaultPrompts struct {
+	vaultPath string
+	folder    string
+	reader    *vault.Reader
+}
+
+// ListPrompts returns builtin prompts merged with the vault's prompt notes
+func (v *vaultPrompts) ListPrompts() ([]Prompt, error) {
+	defs, err := v.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prompts := make([]Prompt, 0, len(names))
+	for _, name := range names {
+		prompts = append(prompts, defs[name].Prompt)
+	}
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (v *vaultPrompts) GetPrompt(name string, args map[string]string) (*PromptResult, error) {
+	defs, err := v.load()
+	if err != nil {
+		return nil, err
+	}
+	def, ok := defs[name]
+	if !ok {
+		return nil, &InvalidPromptError{Prompt: name, Message: "not found"}
+	}
+
+	values := make(map[string]string)
+	for _, arg := range def.args {
+		value, ok := args[arg.Name]
+		if !ok || value == "" {
+			if arg.Required {
+				return nil, &InvalidPromptError{Prompt: name, Message: fmt.Sprintf("missing required argument %q", arg.Name)}
+			}
+			value = arg.Default
+		}
+		values[arg.Name] = value
+	}
+
+	text, err := v.render(def.body, values)
+	if err != nil {
+		return nil, &InvalidPromptError{Prompt: name, Message: err.Error()}
+	}
+
+	return &PromptResult{
+		Description: def.Description,
+		Messages: []PromptMessage{{
+			Role:    "user",
+			Content: Content{Type: "text", Text: strings.TrimSpace(text)},
+		}},
+	}, nil
+}
+
+// load parses the builtin prompts and every note in the prompts folder
+func (v *vaultPrompts) load() (map[string]*promptDef, error) {
+	defs := make(map[string]*promptDef)
+	for name, source := range builtinPrompts {
+		def, err := parsePrompt(name, source)
+		if err != nil {
+			return nil, fmt.Errorf("builtin prompt %s: %w", name, err)
+		}
+		defs[name] = def
+	}
+
+	dir := filepath.Join(v.vaultPath, v.folder)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return defs, nil
+		}
+		return nil, fmt.Errorf("failed to read prompts folder: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := v.reader.ReadNote(filepath.Join(v.folder, entry.Name()))
+		if err != nil {
+			continue
+		}
+		def, err := parsePrompt(strings.TrimSuffix(entry.Name(), ".md"), content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Skipping prompt %s: %v\n", entry.Name(), err)
+			continue
+		}
+		defs[def.Name] = def
+	}
+	return defs, nil
+}
+
+// parsePrompt builds a prompt from a note. The frontmatter may declare
+// name, title, description and arguments (name, description, required, default).
+func parsePrompt(defaultName, content string) (*promptDef, error) {
+	fm, body, err := vault.ParseFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+
+	def := &promptDef{body: body}
+	def.Name = stringField(fm, "name", defaultName)
+	def.Title = stringField(fm, "title", "")
+	def.Description = stringField(fm, "description", "")
+
+	rawArgs, _ := fm["arguments"].([]interface{})
+	for i, raw := range rawArgs {
+		// yaml.v3 decodes nested mappings into the parent's named map type
+		var m map[string]interface{}
+		switch v := raw.(type) {
+		case vault.Frontmatter:
+			m = v
+		case map[string]interface{}:
+			m = v
+		default:
+			return nil, fmt.Errorf("argument %d must be a mapping", i+1)
+		}
+		arg := promptArgumentDef{}
+		arg.Name = stringField(m, "name", "")
+		if arg.Name == "" {
+			return nil, fmt.Errorf("argument %d has no name", i+1)
+		}
+		arg.Description = stringField(m, "description", "")
+		arg.Required, _ = m["required"].(bool)
+		arg.Default = stringField(m, "default", "")
+
+		def.args = append(def.args, arg)
+		def.Arguments = append(def.Arguments, arg.PromptArgument)
+	}
+	return def, nil
+}
+
+// render substitutes placeholders in a prompt body:
+//
+//	{{arg}}             value of an argument
+//	{{note:arg}}        content of the note named by an argument
+//	{{daily_notes:N}}   the last N daily notes (N literal or an argument name)
+//	{{date}}            today's date, unless an argument is named date
+func (v *vaultPrompts) render(body string, values map[string]string) (string, error) {
+	var renderErr error
+
+	out := promptVarRegex.ReplaceAllStringFunc(body, func(match string) string {
+		parts := promptVarRegex.FindStringSubmatch(match)
+		name, param := parts[1], strings.TrimSpace(parts[2])
+
+		if param == "" {
+			if value, ok := values[name]; ok {
+				return value
+			}
+			if name == "date" {
+				return time.Now().Format("2006-01-02")
+			}
+			return match
+		}
+
+		switch name {
+		case "note":
+			path := values[param]
+			if path == "" {
+				return ""
+			}
+			content, err := v.reader.ReadNote(path)
+			if err != nil {
+				renderErr = err
+				return ""
+			}
+			return content
+
+		case "daily_notes":
+			if value, ok := values[param]; ok {
+				param = value
+			}
+			days, err := strconv.Atoi(param)
+			if err != nil || days < 1 {
+				renderErr = fmt.Errorf("invalid day count %q", param)
+				return ""
+			}
+			return v.dailyNotes(days)
+		}
+		return match
+	})
+
+	return out, renderErr
+}
+
+// dailyNotes concatenates the daily notes of the last n days, oldest first
+func (v *vaultPrompts) dailyNotes(n int) string {
+	var b strings.Builder
+	today := time.Now()
+	for i := n - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format("2006-01-02")
+		content, err := v.reader.GetDailyNote(date)
+		if err != nil {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("---\nDaily note %s:\n%s\n\n", date, content))
+	}
+	if b.Len() == 0 {
+		return "(no daily notes found)"
+	}
+	return b.String()
+}
+
+// stringField returns a frontmatter value as a string, or def if missing
+func stringField(m map[string]interface{}, key, def string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case nil:
+		return def
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+)
+
+// NoteURIPrefix is the URI scheme and authority for vault notes
+const NoteURIPrefix = "obsidian://note/"
+
+// markdownMimeType is reported for every note resource
+const markdownMimeType = "text/markdown"
+
+// NoteURI returns the resource URI for a note path relative to the vault
+func NoteURI(relPath string) string {
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return NoteURIPrefix + strings.Join(segments, "/")
+}
+
+// NotePathFromURI returns the vault-relative note path for a note URI
+func NotePathFromURI(uri string) (string, error) {
+	if !strings.HasPrefix(uri, NoteURIPrefix) {
+		return "", fmt.Errorf("not a note uri: %s", uri)
+	}
+	rel, err := url.PathUnescape(strings.TrimPrefix(uri, NoteURIPrefix))
+	if err != nil || rel == "" {
+		return "", fmt.Errorf("invalid note uri: %s", uri)
+	}
+	return filepath.FromSlash(rel), nil
+}
+
+// vaultResources exposes notes as resources and folders as resource templates
+type vaultResources struct {
+	vaultPath string
+	reader    *vault.Reader
+	policy    *policy.Policy
+}
+
+// ListResources lists every markdown note in the vault
+func (v *vaultResources) ListResources() ([]Resource, error) {
+	var resources []Resource
+
+	err := walkNotes(v.vaultPath, func(relPath string, info os.FileInfo) {
+		if !v.policy.CanRead(relPath) {
+			return
+		}
+		resource := Resource{
+			URI:      NoteURI(relPath),
+			Name:     filepath.ToSlash(relPath),
+			Title:    strings.TrimSuffix(info.Name(), ".md"),
+			MimeType: markdownMimeType,
+			Size:     info.Size(),
+		}
+		if fm := v.frontmatter(relPath); len(fm) > 0 {
+			resource.Meta = map[string]interface{}{"frontmatter": fm}
+		}
+		resources = append(resources, resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resources == nil {
+		resources = []Resource{}
+	}
+	return resources, nil
+}
+
+// ListResourceTemplates returns one template per folder plus a vault-wide template
+func (v *vaultResources) ListResourceTemplates() ([]ResourceTemplate, error) {
+	folders := make(map[string]bool)
+	err := walkNotes(v.vaultPath, func(relPath string, info os.FileInfo) {
+		if !v.policy.CanRead(relPath) {
+			return
+		}
+		if dir := filepath.Dir(relPath); dir != "." {
+			folders[filepath.ToSlash(dir)] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []ResourceTemplate{{
+		URITemplate: NoteURIPrefix + "{+path}",
+		Name:        "note",
+		Title:       "Note by path",
+		Description: "Any note in the vault by its path relative to the vault root",
+		MimeType:    markdownMimeType,
+	}}
+
+	sorted := make([]string, 0, len(folders))
+	for folder := range folders {
+		sorted = append(sorted, folder)
+	}
+	sort.Strings(sorted)
+
+	for _, folder := range sorted {
+		templates = append(templates, ResourceTemplate{
+			URITemplate: strings.TrimSuffix(NoteURI(folder), "/") + "/{name}",
+			Name:        folder,
+			Title:       "Notes in " + folder,
+			Description: fmt.Sprintf("A note in the %s folder", folder),
+			MimeType:    markdownMimeType,
+		})
+	}
+	return templates, nil
+}
+
+// ReadResource reads the note addressed by a note URI
+func (v *vaultResources) ReadResource(uri string) (*ResourceContents, error) {
+	relPath, err := NotePathFromURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := v.reader.ReadNote(relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &ResourceContents{
+		URI:      uri,
+		MimeType: markdownMimeType,
+		Text:     content,
+	}
+	if fm, _, err := vault.ParseFrontmatter(content); err == nil && len(fm) > 0 {
+		contents.Meta = map[string]interface{}{"frontmatter": fm}
+	}
+	return contents, nil
+}
+
+// frontmatter returns a note's parsed frontmatter, or nil if it has none or is invalid
+func (v *vaultResources) frontmatter(relPath string) vault.Frontmatter {
+	content, err := v.reader.ReadNote(relPath)
+	if err != nil {
+		return nil
+	}
+	fm, _, err := vault.ParseFrontmatter(content)
+	if err != nil {
+		return nil
+	}
+	return fm
+}
+
+// walkNotes calls fn for every markdown note, skipping hidden files and folders
+func walkNotes(vaultPath string, fn func(relPath string, info os.FileInfo)) error {
+	return filepath.Walk(vaultPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && path != vaultPath {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".md") {
+			relPath, _ := filepath.Rel(vaultPath, path)
+			fn(relPath, info)
+		}
+		return nil
+	})
+}
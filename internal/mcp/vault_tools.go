@@ -0,0 +1,998 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/gardener"
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
+	"github.com/chadmowery/obsidian-agent-tools/internal/vectorstore"
+)
+
+// ServerName is reported to clients in serverInfo
+const ServerName = "obsidian-agent-tools"
+
+// Config holds configuration for the vault-backed MCP server
+type Config struct {
+	// VaultPath is the absolute path to the Obsidian vault
+	VaultPath string
+
+	// Version is reported to clients in serverInfo
+	// Default: dev
+	Version string
+
+	// PromptsFolder is the vault folder holding prompt notes
+	// Default: Prompts
+	PromptsFolder string
+
+	// Policy restricts what agents may read, write and call (nil allows all)
+	Policy *policy.Policy
+
+	// Git commits agent writes to the vault's git repository, per
+	// operation or per session. Default: off
+	Git vault.GitConfig
+
+	// LockTimeout is how long tools wait for notes other processes are
+	// using before failing with a note busy error
+	// Default: vault.DefaultLockTimeout
+	LockTimeout time.Duration
+
+	// OpenVectorStore connects to the vector store. It is called lazily on the
+	// first semantic search so the server starts even when Qdrant is down.
+	OpenVectorStore func() (vectorstore.VectorStore, error)
+}
+
+// vaultTools binds the tool handlers to a vault
+type vaultTools struct {
+	config Config
+	reader *vault.Reader
+	writer *vault.Writer
+	finder *gardener.OrphanFinder
+
+	storeMu sync.Mutex
+	store   vectorstore.VectorStore
+
+	// committers batch each session's writes in vault.GitPerSession mode
+	gitMu      sync.Mutex
+	committers map[*Session]*vault.GitCommitter
+}
+
+// NewVaultServer creates a server exposing the vault, gardener and vector
+// store operations as MCP tools. All transports serve this same registry.
+func NewVaultServer(config Config) *Server {
+	if config.Version == "" {
+		config.Version = "dev"
+	}
+	if config.PromptsFolder == "" {
+		config.PromptsFolder = DefaultPromptsFolder
+	}
+
+	s := NewServer(ServerName, config.Version)
+	s.SetInstructions("Tools for reading, searching and writing notes in an Obsidian vault. " +
+		"Note paths are relative to the vault root; the .md extension is optional. " +
+		"Existing notes can also be named like a [[wikilink]]: by unique name, alias or shortest unambiguous path.")
+
+	t := &vaultTools{
+		config: config,
+		reader: vault.NewReader(config.VaultPath),
+		writer: vault.NewWriter(config.VaultPath),
+		finder: gardener.NewOrphanFinder(config.VaultPath),
+	}
+	locker := vault.NewNoteLocker(config.VaultPath)
+	if config.LockTimeout > 0 {
+		locker.SetTimeout(config.LockTimeout)
+	}
+	t.reader.SetPolicy(config.Policy)
+	t.reader.SetLocker(locker)
+	t.writer.SetPolicy(config.Policy)
+	t.writer.SetLocker(locker)
+	t.writer.SetJournal(vault.NewJournal(config.VaultPath))
+	if config.Git.Mode == vault.GitPerOperation {
+		if committer, err := vault.NewGitCommitter(config.VaultPath, config.Git); err != nil {
+			log.Printf("Not committing vault changes: %v", err)
+		} else {
+			t.writer.SetGit(committer)
+		}
+	}
+	t.finder.SetPolicy(config.Policy)
+
+	t.register(s)
+	s.OnSessionClose(t.flushSession)
+	s.SetToolFilter(func(tool *Tool) error {
+		if err := config.Policy.CheckTool(tool.Name); err != nil {
+			return err
+		}
+		if config.Policy != nil && config.Policy.ReadOnly && !tool.IsReadOnly() {
+			return &policy.PermissionError{Op: policy.OpTool, Path: tool.Name, Reason: "modifies the vault, which is read-only"}
+		}
+		return nil
+	})
+	s.SetResourceProvider(&vaultResources{vaultPath: config.VaultPath, reader: t.reader, policy: config.Policy})
+	s.SetPromptProvider(&vaultPrompts{vaultPath: config.VaultPath, folder: config.PromptsFolder, reader: t.reader})
+	return s
+}
+
+type dryRunKey struct{}
+
+// writerFor returns the vault writer with its changes attributed to the
+// tool call in ctx, or the call's dry run writer
+func (t *vaultTools) writerFor(ctx context.Context) *vault.Writer {
+	if writer, ok := ctx.Value(dryRunKey{}).(*vault.Writer); ok {
+		return writer
+	}
+	writer := t.writer.WithSource("mcp " + ToolName(ctx))
+	if committer := t.sessionCommitter(ctx); committer != nil {
+		writer.SetGit(committer)
+	}
+	return writer
+}
+
+// sessionCommitter returns the committer batching the writes of the
+// session in ctx, or nil unless committing per session
+func (t *vaultTools) sessionCommitter(ctx context.Context) *vault.GitCommitter {
+	sess := SessionFromContext(ctx)
+	if t.config.Git.Mode != vault.GitPerSession || sess == nil {
+		return nil
+	}
+	t.gitMu.Lock()
+	defer t.gitMu.Unlock()
+	if committer, ok := t.committers[sess]; ok {
+		return committer
+	}
+	committer, err := vault.NewGitCommitter(t.config.VaultPath, t.config.Git)
+	if err != nil {
+		log.Printf("Not committing vault changes: %v", err)
+	}
+	if t.committers == nil {
+		t.committers = make(map[*Session]*vault.GitCommitter)
+	}
+	// A failed committer is remembered as nil so the error is logged once
+	t.committers[sess] = committer
+	return committer
+}
+
+// flushSession commits the writes a session made when it ends
+func (t *vaultTools) flushSession(sess *Session) {
+	t.gitMu.Lock()
+	committer := t.committers[sess]
+	delete(t.committers, sess)
+	t.gitMu.Unlock()
+
+	if committer != nil {
+		if err := committer.Flush(); err != nil {
+			log.Printf("Failed to commit session changes: %v", err)
+		}
+	}
+}
+
+// isDryRun reports whether the tool call in ctx is a dry run, so side
+// effects outside the writer, such as indexing, are skipped
+func isDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(*vault.Writer)
+	return ok
+}
+
+// addTool registers a tool. Tools that modify the vault take a dry_run
+// argument that returns the changes they would make as unified diffs.
+func (t *vaultTools) addTool(s *Server, tool *Tool) {
+	if !tool.IsReadOnly() {
+		if tool.InputSchema == nil {
+			tool.InputSchema = ObjectSchema(nil)
+		}
+		if tool.InputSchema.Properties == nil {
+			tool.InputSchema.Properties = make(map[string]*Schema)
+		}
+		tool.InputSchema.Properties["dry_run"] = BooleanProp("Return the changes as unified diffs without making them")
+		handler := tool.Handler
+		tool.Handler = func(ctx context.Context, args Arguments) (*ToolResult, error) {
+			if !args.Bool("dry_run") {
+				return handler(ctx, args)
+			}
+			writer := t.writerFor(ctx).DryRun()
+			if _, err := handler(context.WithValue(ctx, dryRunKey{}, writer), args); err != nil {
+				return nil, err
+			}
+			return dryRunResult(writer.Planned())
+		}
+	}
+	s.AddTool(tool)
+}
+
+// dryRunResult lists planned changes as unified diffs
+func dryRunResult(changes []vault.FileChange) (*ToolResult, error) {
+	var sb strings.Builder
+	entries := make([]interface{}, len(changes))
+	for i, change := range changes {
+		sb.WriteString(change.Diff())
+		entries[i] = map[string]interface{}{"path": change.Path, "change": change.Kind(), "diff": change.Diff()}
+	}
+	if len(changes) == 0 {
+		sb.WriteString("Nothing would change")
+	}
+	result := TextResult(sb.String())
+	result.StructuredContent = map[string]interface{}{"dry_run": true, "changes": entries}
+	return result, nil
+}
+
+// register adds every vault tool to the server
+func (t *vaultTools) register(s *Server) {
+	t.addTool(s, &Tool{
+		Name:        "read_note",
+		Description: "Read the full markdown content of a note, including frontmatter. The note's path and version are returned as structured content; pass the version as expected_version to edits so they fail instead of overwriting changes made in the meantime.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path": StringProp("Note path relative to the vault root, e.g. \"Projects/Roadmap.md\", or a note name or alias such as \"Roadmap\""),
+		}, "path"),
+		Annotations: readOnly,
+		Handler:     t.readNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "search_notes",
+		Description: "Case-insensitive text search across all notes. Returns matching note paths.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"query": StringProp("Text to search for"),
+		}, "query"),
+		Annotations: readOnly,
+		Handler:     t.searchNotes,
+	})
+	t.addTool(s, &Tool{
+		Name:        "semantic_search",
+		Description: "Find notes semantically related to a query using vector embeddings.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"query": StringProp("Natural language query"),
+			"limit": {Type: "integer", Description: "Maximum number of results", Default: 5},
+		}, "query"),
+		Annotations: readOnly,
+		Handler:     t.semanticSearch,
+	})
+	t.addTool(s, &Tool{
+		Name:        "get_daily_note",
+		Description: "Read the daily note for a date.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"date": StringProp("Date as YYYY-MM-DD, or \"today\" (default)"),
+		}),
+		Annotations: readOnly,
+		Handler:     t.getDailyNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "list_tags",
+		Description: "List every #tag used in the vault.",
+		Annotations: readOnly,
+		Handler:     t.listTags,
+	})
+	t.addTool(s, &Tool{
+		Name:        "append_to_note",
+		Description: "Append text to the end of a note, creating the note if it does not exist.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"text":             StringProp("Markdown text to append"),
+			"expected_version": expectedVersionProp,
+		}, "path", "text"),
+		Handler: t.appendToNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "insert_under_heading",
+		Description: "Insert text at the end of a heading's section (up to the next heading of the same or higher level). The heading is added at the end of the note if missing.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"heading":          StringProp("Heading text, optionally with its level, e.g. \"## Log\" or \"Log\""),
+			"text":             StringProp("Markdown text to insert"),
+			"expected_version": expectedVersionProp,
+		}, "path", "heading", "text"),
+		Handler: t.insertUnderHeading,
+	})
+	t.addTool(s, &Tool{
+		Name:        "replace_section",
+		Description: "Replace the body of a heading's section, subheadings included. The heading itself is kept.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"heading":          StringProp("Heading text, optionally with its level, e.g. \"## Status\" or \"Status\""),
+			"text":             StringProp("New markdown body of the section"),
+			"expected_version": expectedVersionProp,
+		}, "path", "heading", "text"),
+		Handler: t.replaceSection,
+	})
+	t.addTool(s, &Tool{
+		Name:        "prepend_to_note",
+		Description: "Insert text at the start of a note's body, after its frontmatter.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"text":             StringProp("Markdown text to insert"),
+			"expected_version": expectedVersionProp,
+		}, "path", "text"),
+		Handler: t.prependToNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "replace_block",
+		Description: "Replace the paragraph, list item or other block marked with a ^block-id. The marker is kept so links to the block keep working.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"block_id":         StringProp("Block id, with or without the leading ^"),
+			"text":             StringProp("New markdown for the block"),
+			"expected_version": expectedVersionProp,
+		}, "path", "block_id", "text"),
+		Handler: t.replaceBlock,
+	})
+	t.addTool(s, &Tool{
+		Name:        "replace_in_note",
+		Description: "Find and replace text within a note, literally or with a regular expression. Returns the number of replacements.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"find":             StringProp("Text or regular expression (RE2 syntax) to find"),
+			"replace":          StringProp("Replacement text; with regex, $1 refers to the first group"),
+			"regex":            {Type: "boolean", Description: "Treat find as a regular expression", Default: false},
+			"expected_version": expectedVersionProp,
+		}, "path", "find", "replace"),
+		Handler: t.replaceInNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "append_to_daily_note",
+		Description: "Append a timestamped entry to today's daily note, creating it if needed.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"text": StringProp("Entry text"),
+		}, "text"),
+		Handler: t.appendToDailyNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "get_periodic_note",
+		Description: "Read the daily, weekly, monthly, quarterly or yearly note for a date.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"period": periodProp,
+			"date":   periodDateProp,
+		}, "period"),
+		Annotations: readOnly,
+		Handler:     t.getPeriodicNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "append_to_periodic_note",
+		Description: "Append text to a daily, weekly, monthly, quarterly or yearly note, creating it from the vault's template if needed.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"period": periodProp,
+			"text":   StringProp("Markdown text to append"),
+			"date":   periodDateProp,
+		}, "period", "text"),
+		Handler: t.appendToPeriodicNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "create_periodic_note",
+		Description: "Create a daily, weekly, monthly, quarterly or yearly note from the vault's template. Existing notes are left untouched.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"period": periodProp,
+			"date":   periodDateProp,
+		}, "period"),
+		Handler: t.createPeriodicNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "create_note",
+		Description: "Create a new note with optional frontmatter and template. Fails if the note already exists unless if_exists says otherwise.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":        StringProp("Note path relative to the vault root"),
+			"content":     StringProp("Markdown body of the note"),
+			"frontmatter": {Type: "object", Description: "Frontmatter properties", AdditionalProperties: true},
+			"template":    StringProp("Template note to start from; defaults to the vault's template for the note's folder"),
+			"variables":   {Type: "object", Description: "Custom template variables", AdditionalProperties: true},
+			"if_exists": {
+				Type:        "string",
+				Description: "What to do if the note already exists",
+				Enum:        []string{"fail", "overwrite", "skip"},
+				Default:     "fail",
+			},
+		}, "path"),
+		Handler: t.createNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "update_frontmatter",
+		Description: "Set a single frontmatter property on a note.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":             StringProp("Note path relative to the vault root"),
+			"key":              StringProp("Property name"),
+			"value":            {Description: "Property value (string, number, boolean, list or object)"},
+			"expected_version": expectedVersionProp,
+		}, "path", "key", "value"),
+		Handler: t.updateFrontmatter,
+	})
+	t.addTool(s, &Tool{
+		Name:        "link_notes",
+		Description: "Add a [[wikilink]] from the source note to the target note as a bullet in its Related section (created if missing) or in a frontmatter list property. Does nothing if the source already links to the target. Ambiguous note names get a path-qualified link.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"source":           StringProp("Note that will contain the link"),
+			"target":           StringProp("Note being linked to"),
+			"heading":          {Type: "string", Description: "Section to list the link under", Default: vault.DefaultRelatedHeading},
+			"alias":            StringProp("Display text for the link, as in [[Target|alias]]"),
+			"property":         StringProp("Frontmatter list property to add the link to instead of the section, e.g. related"),
+			"bidirectional":    {Type: "boolean", Description: "Also link the target back to the source", Default: false},
+			"expected_version": expectedVersionProp,
+		}, "source", "target"),
+		Handler: t.linkNotes,
+	})
+	t.addTool(s, &Tool{
+		Name:        "move_note",
+		Description: "Rename or move a note and rewrite every wikilink, embed and markdown link that points to it. Returns the notes whose links were updated, and those skipped because the access policy doesn't allow writing them.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":        StringProp("Note to move"),
+			"destination": StringProp("New note path relative to the vault root, or a folder ending in \"/\" to keep the file name"),
+		}, "path", "destination"),
+		Handler: t.moveNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "delete_note",
+		Description: "Delete a note following the vault's \"Deleted files\" setting (system trash, the vault's .trash folder, or permanently). Returns the notes that still link to it so they can be cleaned up, or the note restored.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path": StringProp("Note to delete"),
+			"trash": {
+				Type:        "string",
+				Description: "Override where the note goes; defaults to the vault's setting",
+				Enum:        []string{"system", "local", "none"},
+			},
+		}, "path"),
+		Handler: t.deleteNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "restore_note",
+		Description: "Restore a note from the vault's .trash folder to where it was deleted from, or to a new path.",
+		InputSchema: ObjectSchema(map[string]*Schema{
+			"path":        StringProp("Note's path or name in .trash"),
+			"destination": StringProp("Path to restore to instead of the original location"),
+		}, "path"),
+		Handler: t.restoreNote,
+	})
+	t.addTool(s, &Tool{
+		Name:        "find_orphans",
+		Description: "List notes with no incoming or outgoing links.",
+		Annotations: readOnly,
+		Handler:     t.findOrphans,
+	})
+	t.addTool(s, &Tool{
+		Name:        "find_dead_ends",
+		Description: "List notes that are linked to but have no outgoing links.",
+		Annotations: readOnly,
+		Handler:     t.findDeadEnds,
+	})
+	t.addTool(s, &Tool{
+		Name:        "vault_stats",
+		Description: "Summarize the vault's link graph: total notes, orphans, dead ends and well linked notes.",
+		Annotations: readOnly,
+		Handler:     t.vaultStats,
+	})
+}
+
+func (t *vaultTools) readNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	notePath, content, version, err := t.reader.ReadNoteVersion(path)
+	if err != nil {
+		return nil, err
+	}
+	result := TextResult(content)
+	result.StructuredContent = map[string]interface{}{"path": notePath, "version": version}
+	return result, nil
+}
+
+func (t *vaultTools) searchNotes(ctx context.Context, args Arguments) (*ToolResult, error) {
+	query, err := args.RequireString("query")
+	if err != nil {
+		return nil, err
+	}
+	results, err := t.reader.SearchNotes(query)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []string{}
+	}
+	return JSONResult(results)
+}
+
+func (t *vaultTools) semanticSearch(ctx context.Context, args Arguments) (*ToolResult, error) {
+	query, err := args.RequireString("query")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := args.Int("limit", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := t.vectorStore()
+	if err != nil {
+		return nil, err
+	}
+	results, err := store.SemanticSearch(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	type hit struct {
+		Path       string  `json:"path"`
+		Title      string  `json:"title"`
+		Similarity float32 `json:"similarity"`
+		Excerpt    string  `json:"excerpt"`
+	}
+	hits := make([]hit, 0, len(results))
+	for _, r := range results {
+		if !t.config.Policy.CanRead(r.Document.ID) {
+			continue
+		}
+		hits = append(hits, hit{
+			Path:       r.Document.ID,
+			Title:      r.Document.Title,
+			Similarity: r.Similarity,
+			Excerpt:    r.Document.Content,
+		})
+	}
+	return JSONResult(hits)
+}
+
+// vectorStore connects to the vector store on first use
+func (t *vaultTools) vectorStore() (vectorstore.VectorStore, error) {
+	t.storeMu.Lock()
+	defer t.storeMu.Unlock()
+
+	if t.store != nil {
+		return t.store, nil
+	}
+	if t.config.OpenVectorStore == nil {
+		return nil, fmt.Errorf("semantic search is not configured")
+	}
+	store, err := t.config.OpenVectorStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	t.store = store
+	return store, nil
+}
+
+func (t *vaultTools) getDailyNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	content, err := t.reader.GetDailyNote(args.String("date"))
+	if err != nil {
+		return nil, err
+	}
+	return TextResult(content), nil
+}
+
+func (t *vaultTools) listTags(ctx context.Context, args Arguments) (*ToolResult, error) {
+	tags, err := t.reader.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	return JSONResult(tags)
+}
+
+func (t *vaultTools) appendToNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	text, err := args.RequireString("text")
+	if err != nil {
+		return nil, err
+	}
+	version, err := t.writerFor(ctx).AppendToNote(path, text, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Appended to %s", path), version), nil
+}
+
+func (t *vaultTools) insertUnderHeading(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, heading, text, err := sectionArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	version, err := t.writerFor(ctx).InsertUnderHeading(path, heading, text, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Inserted under %q in %s", heading, path), version), nil
+}
+
+func (t *vaultTools) replaceSection(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, heading, text, err := sectionArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	version, err := t.writerFor(ctx).ReplaceSection(path, heading, text, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Replaced section %q in %s", heading, path), version), nil
+}
+
+// sectionArgs decodes the arguments shared by the heading tools
+func sectionArgs(args Arguments) (path, heading, text string, err error) {
+	if path, err = args.RequireString("path"); err != nil {
+		return "", "", "", err
+	}
+	if heading, err = args.RequireString("heading"); err != nil {
+		return "", "", "", err
+	}
+	if text, err = args.RequireString("text"); err != nil {
+		return "", "", "", err
+	}
+	return path, heading, text, nil
+}
+
+func (t *vaultTools) prependToNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	text, err := args.RequireString("text")
+	if err != nil {
+		return nil, err
+	}
+	version, err := t.writerFor(ctx).PrependToNote(path, text, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Prepended to %s", path), version), nil
+}
+
+func (t *vaultTools) replaceBlock(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	blockID, err := args.RequireString("block_id")
+	if err != nil {
+		return nil, err
+	}
+	text, err := args.RequireString("text")
+	if err != nil {
+		return nil, err
+	}
+	version, err := t.writerFor(ctx).ReplaceBlock(path, blockID, text, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Replaced block %s in %s", blockID, path), version), nil
+}
+
+func (t *vaultTools) replaceInNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	find, err := args.RequireString("find")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := args["replace"]; !ok {
+		return nil, fmt.Errorf("missing required argument %q", "replace")
+	}
+	version, count, err := t.writerFor(ctx).ReplaceInNote(path, find, args.String("replace"), args.Bool("regex"), args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	result := versionResult(fmt.Sprintf("Made %d replacement(s) in %s", count, path), version)
+	result.StructuredContent["replacements"] = count
+	return result, nil
+}
+
+func (t *vaultTools) appendToDailyNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	text, err := args.RequireString("text")
+	if err != nil {
+		return nil, err
+	}
+	if err := t.writerFor(ctx).AppendToDailyNote(text); err != nil {
+		return nil, err
+	}
+	return TextResult("Appended to daily note"), nil
+}
+
+// periodProp and periodDateProp describe the arguments of the periodic note tools
+var (
+	periodProp = &Schema{
+		Type:        "string",
+		Description: "Which periodic note",
+		Enum:        []string{"day", "week", "month", "quarter", "year"},
+	}
+	periodDateProp = StringProp("Any date in the period (YYYY-MM-DD), a period such as 2025-W07, 2025-02, 2025-Q1 or 2025, " +
+		"or \"this\" (default), \"last\" or \"next\"")
+)
+
+// periodArgs decodes the period and date arguments of the periodic note tools
+func periodArgs(args Arguments) (vault.Period, time.Time, error) {
+	name, err := args.RequireString("period")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	period, err := vault.ParsePeriod(name)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	date, err := vault.ParsePeriodDate(period, args.String("date"), time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return period, date, nil
+}
+
+func (t *vaultTools) getPeriodicNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	period, date, err := periodArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	content, err := t.reader.GetPeriodicNote(period, date)
+	if err != nil {
+		return nil, err
+	}
+	return TextResult(content), nil
+}
+
+func (t *vaultTools) appendToPeriodicNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	period, date, err := periodArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	text, err := args.RequireString("text")
+	if err != nil {
+		return nil, err
+	}
+	path, err := t.writerFor(ctx).AppendToPeriodicNote(period, date, text)
+	if err != nil {
+		return nil, err
+	}
+	return TextResult(fmt.Sprintf("Appended to %s", path)), nil
+}
+
+func (t *vaultTools) createPeriodicNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	period, date, err := periodArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	path, created, err := t.writerFor(ctx).CreatePeriodicNote(period, date)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return TextResult(fmt.Sprintf("%s already exists", path)), nil
+	}
+	return TextResult(fmt.Sprintf("Created %s", path)), nil
+}
+
+func (t *vaultTools) createNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	fm, err := args.Object("frontmatter")
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := args.Object("variables")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := vault.CreateOptions{
+		Frontmatter: vault.Frontmatter(fm),
+		Template:    args.String("template"),
+		Vars:        vars,
+		IfExists:    vault.ExistsPolicy(args.String("if_exists")),
+	}
+	switch opts.IfExists {
+	case "", vault.ExistsFail, vault.ExistsOverwrite, vault.ExistsSkip:
+	default:
+		return nil, fmt.Errorf("argument \"if_exists\" must be fail, overwrite or skip")
+	}
+
+	notePath, status, err := t.writerFor(ctx).CreateNoteWithOptions(path, args.String("content"), opts)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case vault.StatusSkipped:
+		return TextResult(fmt.Sprintf("%s already exists", notePath)), nil
+	case vault.StatusOverwritten:
+		return TextResult(fmt.Sprintf("Overwrote %s", notePath)), nil
+	}
+	return TextResult(fmt.Sprintf("Created %s", notePath)), nil
+}
+
+func (t *vaultTools) updateFrontmatter(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	key, err := args.RequireString("key")
+	if err != nil {
+		return nil, err
+	}
+	value, ok := args["value"]
+	if !ok {
+		return nil, fmt.Errorf("missing required argument %q", "value")
+	}
+	version, err := t.writerFor(ctx).UpdateFrontmatter(path, key, value, args.String("expected_version"))
+	if err != nil {
+		return nil, err
+	}
+	return versionResult(fmt.Sprintf("Set %s on %s", key, path), version), nil
+}
+
+func (t *vaultTools) linkNotes(ctx context.Context, args Arguments) (*ToolResult, error) {
+	source, err := args.RequireString("source")
+	if err != nil {
+		return nil, err
+	}
+	target, err := args.RequireString("target")
+	if err != nil {
+		return nil, err
+	}
+	result, err := t.writerFor(ctx).LinkNotes(source, target, vault.LinkOptions{
+		Heading:         args.String("heading"),
+		Alias:           args.String("alias"),
+		Property:        args.String("property"),
+		Bidirectional:   args.Bool("bidirectional"),
+		ExpectedVersion: args.String("expected_version"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := JSONResult(result)
+	if err != nil {
+		return nil, err
+	}
+	res.StructuredContent = map[string]interface{}{
+		"source":         result.Source,
+		"target":         result.Target,
+		"link":           result.Link,
+		"added":          result.Added,
+		"version":        result.Version,
+		"linked_back":    result.LinkedBack,
+		"target_version": result.TargetVersion,
+	}
+	return res, nil
+}
+
+func (t *vaultTools) moveNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	destination, err := args.RequireString("destination")
+	if err != nil {
+		return nil, err
+	}
+	result, err := t.writerFor(ctx).MoveNote(path, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the note's embeddings under its new path when semantic search is available
+	indexed := false
+	if t.config.OpenVectorStore != nil && !isDryRun(ctx) {
+		if store, err := t.vectorStore(); err == nil {
+			indexed = store.RenameDocument(result.From, result.To) == nil
+		}
+	}
+
+	res, err := JSONResult(result)
+	if err != nil {
+		return nil, err
+	}
+	res.StructuredContent = map[string]interface{}{
+		"from":    result.From,
+		"to":      result.To,
+		"updated": result.Updated,
+		"links":   result.Links,
+		"skipped": result.Skipped,
+		"indexed": indexed,
+	}
+	return res, nil
+}
+
+func (t *vaultTools) deleteNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	var trash vault.TrashOption
+	if option := args.String("trash"); option != "" {
+		if trash, err = vault.ParseTrashOption(option); err != nil {
+			return nil, err
+		}
+	}
+	result, err := t.writerFor(ctx).DeleteNote(path, trash)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := false
+	if t.config.OpenVectorStore != nil && !isDryRun(ctx) {
+		if store, err := t.vectorStore(); err == nil {
+			indexed = store.RemoveDocument(result.Path) == nil
+		}
+	}
+
+	res, err := JSONResult(result)
+	if err != nil {
+		return nil, err
+	}
+	res.StructuredContent = map[string]interface{}{
+		"path":      result.Path,
+		"trash":     result.Trash,
+		"location":  result.Location,
+		"backlinks": result.Backlinks,
+		"indexed":   indexed,
+	}
+	return res, nil
+}
+
+func (t *vaultTools) restoreNote(ctx context.Context, args Arguments) (*ToolResult, error) {
+	path, err := args.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	restored, err := t.writerFor(ctx).RestoreNote(path, args.String("destination"))
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := false
+	if t.config.OpenVectorStore != nil && !isDryRun(ctx) {
+		if store, err := t.vectorStore(); err == nil {
+			if content, err := t.reader.ReadNote(restored); err == nil {
+				title := vault.ParseDocument(content).Title()
+				if title == "" {
+					title = strings.TrimSuffix(filepath.Base(restored), ".md")
+				}
+				indexed = store.IndexDocument(restored, title, content) == nil
+			}
+		}
+	}
+
+	result := TextResult(fmt.Sprintf("Restored %s", restored))
+	result.StructuredContent = map[string]interface{}{"path": restored, "indexed": indexed}
+	return result, nil
+}
+
+// expectedVersionProp describes the optimistic concurrency argument of edit tools
+var expectedVersionProp = StringProp("Version returned by read_note or a previous edit. " +
+	"If the note has changed since, the edit fails with a conflict error carrying the current version")
+
+// versionResult reports a successful edit along with the note's new version
+func versionResult(text, version string) *ToolResult {
+	result := TextResult(fmt.Sprintf("%s (version %s)", text, version))
+	result.StructuredContent = map[string]interface{}{"version": version}
+	return result
+}
+
+func (t *vaultTools) findOrphans(ctx context.Context, args Arguments) (*ToolResult, error) {
+	orphans, err := t.finder.FindOrphans()
+	if err != nil {
+		return nil, err
+	}
+	if orphans == nil {
+		orphans = []string{}
+	}
+	return JSONResult(orphans)
+}
+
+func (t *vaultTools) findDeadEnds(ctx context.Context, args Arguments) (*ToolResult, error) {
+	deadEnds, err := t.finder.FindDeadEnds()
+	if err != nil {
+		return nil, err
+	}
+	if deadEnds == nil {
+		deadEnds = []string{}
+	}
+	return JSONResult(deadEnds)
+}
+
+func (t *vaultTools) vaultStats(ctx context.Context, args Arguments) (*ToolResult, error) {
+	stats, err := t.finder.GetLinkStats()
+	if err != nil {
+		return nil, err
+	}
+	return JSONResult(stats)
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+)
+
+func TestReadOnlyHidesWriteTools(t *testing.T) {
+	vaultPath := t.TempDir()
+	all := NewVaultServer(Config{VaultPath: vaultPath}).Tools()
+	s := NewVaultServer(Config{VaultPath: vaultPath, Policy: &policy.Policy{ReadOnly: true}})
+
+	listed := make(map[string]bool)
+	for _, tool := range s.Tools() {
+		listed[tool.Name] = true
+	}
+	writes := 0
+	for _, tool := range all {
+		registered, ok := s.lookupTool(tool.Name)
+		if !ok {
+			t.Fatalf("tool %s is not registered", tool.Name)
+		}
+		err := s.checkTool(registered)
+		if tool.IsReadOnly() {
+			if !listed[tool.Name] || err != nil {
+				t.Errorf("read-only tool %s is blocked: %v", tool.Name, err)
+			}
+			continue
+		}
+		writes++
+		if listed[tool.Name] {
+			t.Errorf("write tool %s is listed on a read-only server", tool.Name)
+		}
+		if !policy.IsPermissionError(err) {
+			t.Errorf("write tool %s can be called on a read-only server: %v", tool.Name, err)
+		}
+	}
+	if writes == 0 {
+		t.Error("found no write tools to check")
+	}
+}
+
+func TestToolLists(t *testing.T) {
+	vaultPath := t.TempDir()
+	s := NewVaultServer(Config{VaultPath: vaultPath, Policy: &policy.Policy{
+		EnabledTools:  []string{"read_note", "append_to_note", "semantic_search"},
+		DisabledTools: []string{"semantic_search"},
+	}})
+	var names []string
+	for _, tool := range s.Tools() {
+		names = append(names, tool.Name)
+	}
+	if len(names) != 2 || names[0] != "read_note" || names[1] != "append_to_note" {
+		t.Errorf("got tools %v, want [read_note append_to_note]", names)
+	}
+}
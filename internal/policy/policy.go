@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// foldCase makes patterns case-insensitive where the filesystem usually is,
+// so "Private" also blocks "private/secret.md" on macOS and Windows
+var foldCase = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// Operation is the kind of access being checked
+type Operation string
+
+const (
+	OpRead  Operation = "read"
+	OpWrite Operation = "write"
+	OpTool  Operation = "tool"
+)
+
+// Policy restricts what a caller may read, write or invoke.
+// The zero value (and a nil *Policy) allows everything.
+type Policy struct {
+	// ReadOnly denies every write
+	ReadOnly bool
+
+	// ReadAllow limits reads to these folders (empty means the whole vault)
+	ReadAllow []string
+	// ReadDeny blocks reads from these folders, even if allowed above
+	ReadDeny []string
+
+	// WriteAllow limits writes to these folders (empty means the whole vault)
+	WriteAllow []string
+	// WriteDeny blocks writes to these folders, even if allowed above
+	WriteDeny []string
+
+	// EnabledTools limits agents to these tools (empty means all tools)
+	EnabledTools []string
+	// DisabledTools hides these tools from agents
+	DisabledTools []string
+}
+
+// PermissionError reports an operation blocked by the policy
+type PermissionError struct {
+	Op     Operation
+	Path   string
+	Reason string
+}
+
+func (e *PermissionError) Error() string {
+	if e.Op == OpTool {
+		return fmt.Sprintf("permission denied: tool %s %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("permission denied: cannot %s %s: %s", e.Op, e.Path, e.Reason)
+}
+
+// Details returns the error as structured data for JSON and MCP clients
+func (e *PermissionError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":      "permission_denied",
+		"operation": string(e.Op),
+		"path":      e.Path,
+		"reason":    e.Reason,
+	}
+}
+
+// IsPermissionError reports whether err was caused by a policy violation
+func IsPermissionError(err error) bool {
+	var permErr *PermissionError
+	return errors.As(err, &permErr)
+}
+
+// FromEnv builds a policy from environment variables. Lists are comma-separated.
+//
+//	OBSIDIAN_READ_ONLY=true
+//	OBSIDIAN_READ_ALLOW, OBSIDIAN_READ_DENY
+//	OBSIDIAN_WRITE_ALLOW, OBSIDIAN_WRITE_DENY
+//	OBSIDIAN_ENABLED_TOOLS, OBSIDIAN_DISABLED_TOOLS
+func FromEnv() *Policy {
+	return &Policy{
+		ReadOnly:      os.Getenv("OBSIDIAN_READ_ONLY") == "true",
+		ReadAllow:     SplitList(os.Getenv("OBSIDIAN_READ_ALLOW")),
+		ReadDeny:      SplitList(os.Getenv("OBSIDIAN_READ_DENY")),
+		WriteAllow:    SplitList(os.Getenv("OBSIDIAN_WRITE_ALLOW")),
+		WriteDeny:     SplitList(os.Getenv("OBSIDIAN_WRITE_DENY")),
+		EnabledTools:  SplitList(os.Getenv("OBSIDIAN_ENABLED_TOOLS")),
+		DisabledTools: SplitList(os.Getenv("OBSIDIAN_DISABLED_TOOLS")),
+	}
+}
+
+// SplitList splits a comma-separated list, dropping empty entries
+func SplitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// CheckRead returns a PermissionError if relPath may not be read
+func (p *Policy) CheckRead(relPath string) error {
+	if p == nil {
+		return nil
+	}
+	return check(OpRead, relPath, p.ReadAllow, p.ReadDeny)
+}
+
+// CheckWrite returns a PermissionError if relPath may not be written
+func (p *Policy) CheckWrite(relPath string) error {
+	if p == nil {
+		return nil
+	}
+	if p.ReadOnly {
+		return &PermissionError{Op: OpWrite, Path: normalize(relPath), Reason: "vault is read-only"}
+	}
+	return check(OpWrite, relPath, p.WriteAllow, p.WriteDeny)
+}
+
+// CanRead reports whether relPath may be read; used to filter listings
+func (p *Policy) CanRead(relPath string) bool {
+	return p.CheckRead(relPath) == nil
+}
+
+// CheckTool returns a PermissionError if the named tool is disabled
+func (p *Policy) CheckTool(name string) error {
+	if p == nil {
+		return nil
+	}
+	for _, disabled := range p.DisabledTools {
+		if disabled == name {
+			return &PermissionError{Op: OpTool, Path: name, Reason: "is disabled"}
+		}
+	}
+	if len(p.EnabledTools) > 0 {
+		for _, enabled := range p.EnabledTools {
+			if enabled == name {
+				return nil
+			}
+		}
+		return &PermissionError{Op: OpTool, Path: name, Reason: "is not enabled"}
+	}
+	return nil
+}
+
+// check applies an allowlist and denylist to a path. Deny wins over allow.
+func check(op Operation, relPath string, allow, deny []string) error {
+	p := normalize(relPath)
+
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(p) {
+		return &PermissionError{Op: op, Path: p, Reason: "path is outside the vault"}
+	}
+
+	for _, pattern := range deny {
+		if matches(pattern, p) {
+			return &PermissionError{Op: op, Path: p, Reason: fmt.Sprintf("denied by %q", pattern)}
+		}
+	}
+
+	if len(allow) == 0 {
+		return nil
+	}
+	for _, pattern := range allow {
+		if matches(pattern, p) {
+			return nil
+		}
+	}
+	return &PermissionError{Op: op, Path: p, Reason: "not in an allowed folder"}
+}
+
+// matches reports whether a note path falls under a folder or glob pattern.
+// "Journal" matches "Journal/x.md" and everything below it; "Inbox/*.md"
+// matches notes directly in Inbox; a note path matches only itself.
+func matches(pattern, notePath string) bool {
+	pattern = strings.Trim(normalize(pattern), "/")
+	if foldCase {
+		pattern, notePath = strings.ToLower(pattern), strings.ToLower(notePath)
+	}
+	if pattern == "" || pattern == "." {
+		return true
+	}
+	if notePath == pattern || strings.HasPrefix(notePath, pattern+"/") {
+		return true
+	}
+	if ok, _ := path.Match(pattern, notePath); ok {
+		return true
+	}
+	return false
+}
+
+// normalize converts a path to a clean, slash-separated form
+func normalize(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// RegisterFlags binds the policy's fields to command-line flags. Call it on
+// a policy built with FromEnv so flags override the environment.
+func (p *Policy) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&p.ReadOnly, "read-only", p.ReadOnly, "Deny all writes to the vault")
+	fs.Var((*listFlag)(&p.ReadAllow), "allow-read", "Comma-separated folders that may be read (default: all)")
+	fs.Var((*listFlag)(&p.ReadDeny), "deny-read", "Comma-separated folders that may not be read (case-insensitive on macOS and Windows)")
+	fs.Var((*listFlag)(&p.WriteAllow), "allow-write", "Comma-separated folders that may be written (default: all)")
+	fs.Var((*listFlag)(&p.WriteDeny), "deny-write", "Comma-separated folders that may not be written")
+	fs.Var((*listFlag)(&p.EnabledTools), "enable-tools", "Comma-separated MCP tools to expose (default: all)")
+	fs.Var((*listFlag)(&p.DisabledTools), "disable-tools", "Comma-separated MCP tools to hide")
+}
+
+// listFlag is a comma-separated list flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = SplitList(value)
+	return nil
+}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestCheckRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		path   string
+		ok     bool
+	}{
+		{"nil policy", nil, "Private/a.md", true},
+		{"zero policy", &Policy{}, "Private/a.md", true},
+		{"denied folder", &Policy{ReadDeny: []string{"Private"}}, "Private/a.md", false},
+		{"denied subfolder", &Policy{ReadDeny: []string{"Private"}}, "Private/Deep/a.md", false},
+		{"folder prefix is not a match", &Policy{ReadDeny: []string{"Private"}}, "PrivateNotes/a.md", true},
+		{"trailing slash", &Policy{ReadDeny: []string{"Private/"}}, "Private/a.md", false},
+		{"denied note", &Policy{ReadDeny: []string{"Inbox/secret.md"}}, "Inbox/secret.md", false},
+		{"other note", &Policy{ReadDeny: []string{"Inbox/secret.md"}}, "Inbox/other.md", true},
+		{"glob", &Policy{ReadDeny: []string{"Inbox/*.md"}}, "Inbox/a.md", false},
+		{"glob is one level", &Policy{ReadDeny: []string{"Inbox/*.md"}}, "Inbox/Deep/a.md", true},
+		{"glob across folders", &Policy{ReadDeny: []string{"*/secret.md"}}, "Work/secret.md", false},
+		{"allowed folder", &Policy{ReadAllow: []string{"Public"}}, "Public/a.md", true},
+		{"outside allowed folders", &Policy{ReadAllow: []string{"Public"}}, "Other/a.md", false},
+		{"deny wins over allow", &Policy{ReadAllow: []string{"Public"}, ReadDeny: []string{"Public/Drafts"}}, "Public/Drafts/a.md", false},
+		{"deny wins over the same allow", &Policy{ReadAllow: []string{"Public"}, ReadDeny: []string{"Public"}}, "Public/a.md", false},
+		{"uncleaned path", &Policy{ReadDeny: []string{"Private"}}, "Public/../Private/a.md", false},
+		{"traversal", &Policy{}, "../a.md", false},
+		{"absolute", &Policy{}, "/etc/passwd", false},
+		{"root pattern", &Policy{ReadAllow: []string{"."}}, "a.md", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckRead(tt.path)
+			if (err == nil) != tt.ok {
+				t.Errorf("CheckRead(%q) = %v, want ok = %v", tt.path, err, tt.ok)
+			}
+			if err != nil && !IsPermissionError(err) {
+				t.Errorf("got %T, want a PermissionError", err)
+			}
+			if tt.policy.CanRead(tt.path) != tt.ok {
+				t.Errorf("CanRead(%q) disagrees with CheckRead", tt.path)
+			}
+		})
+	}
+}
+
+func TestCheckReadCase(t *testing.T) {
+	defer func(old bool) { foldCase = old }(foldCase)
+	p := &Policy{ReadDeny: []string{"Private", "Inbox/*.MD"}}
+
+	foldCase = false
+	if err := p.CheckRead("private/secret.md"); err != nil {
+		t.Errorf("case-sensitive match denied a differently cased folder: %v", err)
+	}
+	foldCase = true
+	for _, notePath := range []string{"private/secret.md", "PRIVATE/Deep/a.md", "inbox/a.md"} {
+		if err := p.CheckRead(notePath); err == nil {
+			t.Errorf("case-insensitive match allowed %q", notePath)
+		}
+	}
+	if err := p.CheckRead("Public/a.md"); err != nil {
+		t.Errorf("case-insensitive match denied Public/a.md: %v", err)
+	}
+}
+
+func TestCheckWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		path   string
+		ok     bool
+	}{
+		{"allowed", &Policy{WriteAllow: []string{"Inbox"}}, "Inbox/a.md", true},
+		{"not allowed", &Policy{WriteAllow: []string{"Inbox"}}, "Projects/a.md", false},
+		{"denied", &Policy{WriteDeny: []string{"Archive"}}, "Archive/a.md", false},
+		{"read rules don't apply", &Policy{ReadDeny: []string{"Inbox"}}, "Inbox/a.md", true},
+		{"read-only", &Policy{ReadOnly: true}, "Inbox/a.md", false},
+		{"read-only beats allow", &Policy{ReadOnly: true, WriteAllow: []string{"Inbox"}}, "Inbox/a.md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.CheckWrite(tt.path); (err == nil) != tt.ok {
+				t.Errorf("CheckWrite(%q) = %v, want ok = %v", tt.path, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckTool(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		tool   string
+		ok     bool
+	}{
+		{"all tools", &Policy{}, "append_note", true},
+		{"disabled", &Policy{DisabledTools: []string{"semantic_search"}}, "semantic_search", false},
+		{"not disabled", &Policy{DisabledTools: []string{"semantic_search"}}, "read_note", true},
+		{"enabled", &Policy{EnabledTools: []string{"read_note"}}, "read_note", true},
+		{"not enabled", &Policy{EnabledTools: []string{"read_note"}}, "append_note", false},
+		{"disabled wins over enabled", &Policy{EnabledTools: []string{"read_note"}, DisabledTools: []string{"read_note"}}, "read_note", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.CheckTool(tt.tool); (err == nil) != tt.ok {
+				t.Errorf("CheckTool(%q) = %v, want ok = %v", tt.tool, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	p := &Policy{ReadDeny: []string{"FromEnv"}}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--read-only", "--deny-read", "Private, Journal,", "--enable-tools", "read_note"}); err != nil {
+		t.Fatal(err)
+	}
+	want := &Policy{ReadOnly: true, ReadDeny: []string{"Private", "Journal"}, EnabledTools: []string{"read_note"}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+}
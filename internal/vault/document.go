@@ -0,0 +1,501 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// calloutRegex matches the first line of a callout inside a quote,
+	// capturing its type, fold marker and title: [!note]- Title
+	calloutRegex = regexp.MustCompile(`^\[!([^\]]+)\]([+-]?)[ \t]*(.*)$`)
+	// taskRegex matches the checkbox after a list item's marker
+	taskRegex = regexp.MustCompile(`^\[(.)\](?:[ \t]+|$)`)
+	// blockMarkerRegex matches a ^block-id ending a line
+	blockMarkerRegex = regexp.MustCompile(`(?:^|[ \t])\^([A-Za-z0-9-]+)[ \t]*$`)
+)
+
+// BlockKind is the kind of a block of a note's body
+type BlockKind string
+
+const (
+	BlockParagraph BlockKind = "paragraph"
+	BlockListItem  BlockKind = "list_item"
+	BlockTask      BlockKind = "task"
+	BlockCallout   BlockKind = "callout"
+	BlockQuote     BlockKind = "quote"
+	BlockCode      BlockKind = "code"
+)
+
+// Document is a note parsed into the parts Obsidian recognizes. Lines are
+// 0-based and offsets are byte offsets into the whole note, frontmatter
+// included, so they can be used to edit the note's content.
+type Document struct {
+	// Frontmatter is the note's properties, nil if it has none or they
+	// are not valid YAML
+	Frontmatter Frontmatter `json:"frontmatter,omitempty"`
+
+	// BodyStart is the offset of the body, after the frontmatter
+	BodyStart int `json:"body_start"`
+
+	// Headings lists every heading in order; Outline holds the top level
+	// ones, with the headings below them as their children
+	Headings []*Heading `json:"-"`
+	Outline  []*Heading `json:"outline"`
+
+	// Blocks lists the body's paragraphs, list items, callouts, quotes and
+	// code blocks in order
+	Blocks []*Block `json:"blocks"`
+
+	// Links lists the wikilinks, embeds and markdown links outside code,
+	// including wikilinks in properties
+	Links []Link `json:"links"`
+
+	// Tags lists the inline #tags outside code and links
+	Tags []Tag `json:"tags"`
+}
+
+// Heading is a heading and the section it starts
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	// End is the end of the section: the next heading of the same or a
+	// higher level, or the end of the note
+	End      int        `json:"end"`
+	Parent   *Heading   `json:"-"`
+	Children []*Heading `json:"children,omitempty"`
+}
+
+// Block is a paragraph, list item, task, callout, quote or code block
+type Block struct {
+	Kind BlockKind `json:"kind"`
+	// Line and EndLine are the block's first and last lines
+	Line    int `json:"line"`
+	EndLine int `json:"end_line"`
+	Start   int `json:"start"`
+	End     int `json:"end"`
+	// Text is the block's text without list markers, checkboxes, quote
+	// markers, fences or its ^id
+	Text string `json:"text"`
+	// ID is the block's ^id, without the ^
+	ID string `json:"id,omitempty"`
+	// Indent is a list item's or task's indentation, tabs counting as 4
+	Indent int `json:"indent,omitempty"`
+	// Status is a task's checkbox character: " " when open, "x" when done,
+	// or a custom status such as "/" or "-"
+	Status string `json:"status,omitempty"`
+	// Callout, Title and Fold are a callout's type, title and fold marker
+	// ("+", "-" or "")
+	Callout string `json:"callout,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Fold    string `json:"fold,omitempty"`
+	// Language is a code block's language
+	Language string `json:"language,omitempty"`
+}
+
+// Done reports whether the block is a checked task
+func (b *Block) Done() bool {
+	return b.Kind == BlockTask && strings.EqualFold(b.Status, "x")
+}
+
+// Link is a [[wikilink]], ![[embed]] or [markdown](link)
+type Link struct {
+	// Target is the linked note or file as written, without the subpath;
+	// "" for links within the note. Markdown links to notes are decoded.
+	Target string `json:"target"`
+	// Subpath is the #heading or #^block linked to, if any
+	Subpath string `json:"subpath,omitempty"`
+	// Display is the link's |display text or [markdown text]
+	Display  string `json:"display,omitempty"`
+	Embed    bool   `json:"embed,omitempty"`
+	Markdown bool   `json:"markdown,omitempty"`
+	// Property reports a wikilink in the frontmatter
+	Property bool `json:"property,omitempty"`
+	Line     int  `json:"line"`
+	Start    int  `json:"start"`
+	End      int  `json:"end"`
+
+	dest string // a markdown link's destination as written
+}
+
+// Tag is an inline #tag
+type Tag struct {
+	// Name is the tag without the #
+	Name  string `json:"name"`
+	Line  int    `json:"line"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// ParseDocument parses a note's content. Parsing never fails: text that is
+// not a recognized block is a paragraph, and invalid frontmatter is left
+// out.
+func ParseDocument(content string) *Document {
+	d := &Document{Headings: []*Heading{}, Outline: []*Heading{}, Blocks: []*Block{}, Links: []Link{}, Tags: []Tag{}}
+	p := &documentParser{doc: d}
+
+	if m := frontmatterRegex.FindStringIndex(content); m != nil {
+		d.BodyStart = m[1]
+		d.Frontmatter, _, _ = ParseFrontmatter(content)
+	}
+
+	line, offset := 0, 0
+	for offset <= len(content) {
+		end := strings.IndexByte(content[offset:], '\n')
+		next := offset + end + 1
+		if end < 0 {
+			end = len(content) - offset
+			next = len(content) + 1
+		}
+		text := strings.TrimSuffix(content[offset:offset+end], "\r")
+		if offset < d.BodyStart {
+			// Properties can link to notes, but hold no tags or blocks
+			p.scanLinks(text, line, offset, true)
+		} else if offset < len(content) || text != "" {
+			p.parseLine(text, line, offset)
+		}
+		line, offset = line+1, next
+	}
+	p.closeBlock()
+	if p.code != nil {
+		p.closeCode()
+	}
+	for _, h := range p.open {
+		h.End = len(content)
+	}
+	return d
+}
+
+// documentParser holds the state of ParseDocument between lines
+type documentParser struct {
+	doc       *Document
+	open      []*Heading // the current heading and its parents
+	block     *Block     // the paragraph, list item or quote being read
+	lines     []string   // the text lines of block
+	code      *Block     // the open code block
+	fence     string
+	codeLines []string
+}
+
+func (p *documentParser) parseLine(text string, line, offset int) {
+	if p.code != nil {
+		if closesFence(text, p.fence) {
+			p.code.EndLine, p.code.End = line, offset+len(text)
+			p.closeCode()
+			return
+		}
+		p.codeLines = append(p.codeLines, text)
+		p.code.EndLine, p.code.End = line, offset+len(text)
+		return
+	}
+
+	if m := fenceRegex.FindStringSubmatch(text); m != nil {
+		p.closeBlock()
+		language := ""
+		if fields := strings.Fields(text[len(m[0]):]); len(fields) > 0 {
+			language = fields[0]
+		}
+		p.code = &Block{Kind: BlockCode, Line: line, EndLine: line, Start: offset, End: offset + len(text), Language: language}
+		p.fence, p.codeLines = m[1], nil
+		return
+	}
+
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case trimmed == "":
+		p.closeBlock()
+		return
+
+	case headingRegex.MatchString(text):
+		p.closeBlock()
+		p.addHeading(text, line, offset)
+		p.scanInline(text, line, offset)
+		return
+
+	case strings.HasPrefix(trimmed, "^") && blockIDRegex.MatchString(trimmed[1:]):
+		// A marker on its own line labels the block above it
+		p.closeBlock()
+		if n := len(p.doc.Blocks); n > 0 && p.doc.Blocks[n-1].ID == "" {
+			p.doc.Blocks[n-1].ID = trimmed[1:]
+		}
+		return
+	}
+
+	quoted := strings.TrimLeft(text, " ")
+	switch {
+	case len(text)-len(quoted) <= 3 && strings.HasPrefix(quoted, ">"):
+		inner := strings.TrimPrefix(strings.TrimPrefix(quoted, ">"), " ")
+		if p.block == nil || (p.block.Kind != BlockCallout && p.block.Kind != BlockQuote) {
+			p.closeBlock()
+			p.startBlock(BlockQuote, line, offset)
+			if m := calloutRegex.FindStringSubmatch(strings.TrimSpace(inner)); m != nil {
+				p.block.Kind = BlockCallout
+				p.block.Callout = strings.ToLower(strings.TrimSpace(m[1]))
+				p.block.Fold = m[2]
+				p.block.Title = strings.TrimSpace(m[3])
+				break
+			}
+		}
+		p.lines = append(p.lines, inner)
+
+	case listItemRegex.MatchString(text):
+		p.closeBlock()
+		p.startBlock(BlockListItem, line, offset)
+		indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+		p.block.Indent = len(strings.ReplaceAll(indent, "\t", "    "))
+		item := strings.TrimLeft(text[len(listItemRegex.FindString(text)):], " \t")
+		if m := taskRegex.FindStringSubmatch(item); m != nil {
+			p.block.Kind = BlockTask
+			p.block.Status = m[1]
+			item = item[len(m[0]):]
+		}
+		p.lines = append(p.lines, item)
+
+	case p.block != nil && p.block.Kind != BlockCallout && p.block.Kind != BlockQuote:
+		// Lines without a blank line between continue a paragraph or item
+		p.lines = append(p.lines, trimmed)
+
+	default:
+		p.closeBlock()
+		p.startBlock(BlockParagraph, line, offset)
+		p.lines = append(p.lines, trimmed)
+	}
+
+	p.block.EndLine, p.block.End = line, offset+len(text)
+	p.scanInline(text, line, offset)
+
+	if len(p.lines) == 0 {
+		return
+	}
+	last := p.lines[len(p.lines)-1]
+	if m := blockMarkerRegex.FindStringSubmatchIndex(last); m != nil {
+		p.block.ID = last[m[2]:m[3]]
+		p.lines[len(p.lines)-1] = strings.TrimRight(last[:m[0]], " \t")
+		// A marker ends its paragraph or list item
+		if p.block.Kind != BlockCallout && p.block.Kind != BlockQuote {
+			p.closeBlock()
+		}
+	}
+}
+
+// closesFence reports whether a line closes the code block opened by
+// fence: the same character at least as many times, and nothing after it
+func closesFence(line, fence string) bool {
+	m := fenceRegex.FindStringSubmatch(line)
+	return m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(line[len(m[0]):]) == ""
+}
+
+func (p *documentParser) startBlock(kind BlockKind, line, offset int) {
+	p.block = &Block{Kind: kind, Line: line, Start: offset}
+	p.lines = nil
+}
+
+// closeBlock finishes the paragraph, list item or quote being read
+func (p *documentParser) closeBlock() {
+	if p.block == nil {
+		return
+	}
+	p.block.Text = strings.TrimSpace(strings.Join(p.lines, "\n"))
+	p.doc.Blocks = append(p.doc.Blocks, p.block)
+	p.block, p.lines = nil, nil
+}
+
+func (p *documentParser) closeCode() {
+	p.code.Text = strings.Join(p.codeLines, "\n")
+	p.doc.Blocks = append(p.doc.Blocks, p.code)
+	p.code, p.fence, p.codeLines = nil, "", nil
+}
+
+// addHeading adds a heading to the outline, ending the sections of the
+// headings at its level and below
+func (p *documentParser) addHeading(text string, line, offset int) {
+	m := headingRegex.FindStringSubmatch(text)
+	h := &Heading{Level: len(m[1]), Text: strings.TrimSpace(m[2]), Line: line, Start: offset}
+	for len(p.open) > 0 && p.open[len(p.open)-1].Level >= h.Level {
+		p.open[len(p.open)-1].End = offset
+		p.open = p.open[:len(p.open)-1]
+	}
+	if len(p.open) > 0 {
+		h.Parent = p.open[len(p.open)-1]
+		h.Parent.Children = append(h.Parent.Children, h)
+	} else {
+		p.doc.Outline = append(p.doc.Outline, h)
+	}
+	p.open = append(p.open, h)
+	p.doc.Headings = append(p.doc.Headings, h)
+}
+
+// scanInline finds the links and tags on a line of the body
+func (p *documentParser) scanInline(text string, line, offset int) {
+	p.scanLinks(text, line, offset, false)
+	for _, m := range inlineTagMatches(text) {
+		p.doc.Tags = append(p.doc.Tags, Tag{Name: text[m[4]:m[5]], Line: line, Start: offset + m[4] - 1, End: offset + m[5]})
+	}
+}
+
+// scanLinks adds the links on a line outside `inline code` to the
+// document. Properties only hold wikilinks.
+func (p *documentParser) scanLinks(text string, line, offset int, property bool) {
+	if !strings.Contains(text, "[[") && (property || !strings.Contains(text, "](")) {
+		return
+	}
+	code := inlineCodeRegex.FindAllStringIndex(text, -1)
+	inCode := func(i int) bool {
+		for _, c := range code {
+			if i >= c[0] && i < c[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var links []Link
+	for _, m := range wikilinkTargetRegex.FindAllStringSubmatchIndex(text, -1) {
+		if inCode(m[0]) {
+			continue
+		}
+		link := Link{Embed: m[3] > m[2], Target: strings.TrimSpace(text[m[4]:m[5]]), Property: property, Line: line, Start: offset + m[0], End: offset + m[1]}
+		if m[6] >= 0 {
+			link.Subpath = text[m[6]:m[7]]
+		}
+		if m[8] >= 0 {
+			link.Display = text[m[8]+1 : m[9]]
+		}
+		if link.Target != "" || link.Subpath != "" {
+			links = append(links, link)
+		}
+	}
+	if !property {
+		for _, m := range markdownLinkRegex.FindAllStringSubmatchIndex(text, -1) {
+			if inCode(m[0]) {
+				continue
+			}
+			link := Link{Embed: m[3] > m[2], Markdown: true, Display: text[m[4]:m[5]], Line: line, Start: offset + m[0], End: offset + m[1]}
+			link.dest = text[m[6]:m[7]]
+			link.Target = strings.TrimSuffix(strings.TrimPrefix(link.dest, "<"), ">")
+			if parsed, ok := parseMarkdownTarget(link.dest); ok {
+				link.Target, link.Subpath = parsed.path, parsed.subpath
+			} else if strings.HasPrefix(link.Target, "#") {
+				link.Target, link.Subpath = "", link.Target
+			}
+			links = append(links, link)
+		}
+	}
+	p.doc.Links = append(p.doc.Links, links...)
+}
+
+// inCode reports whether an offset falls in a code block
+func (d *Document) inCode(offset int) bool {
+	for _, b := range d.Blocks {
+		if b.Kind == BlockCode && offset >= b.Start && offset <= b.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Title returns the text of the note's first top level heading, or "" if
+// it has none
+func (d *Document) Title() string {
+	for _, h := range d.Headings {
+		if h.Level == 1 {
+			return h.Text
+		}
+	}
+	return ""
+}
+
+// Heading finds the first heading matching spec, given with its level
+// ("## Log") or without ("Log"), ignoring case
+func (d *Document) Heading(spec string) *Heading {
+	level, text := parseHeadingSpec(spec)
+	for _, h := range d.Headings {
+		if (level == 0 || h.Level == level) && strings.EqualFold(h.Text, text) {
+			return h
+		}
+	}
+	return nil
+}
+
+// Block finds the block with a ^id, given with or without the ^
+func (d *Document) Block(id string) *Block {
+	id = strings.TrimPrefix(id, "^")
+	for _, b := range d.Blocks {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// Tasks lists the note's tasks
+func (d *Document) Tasks() []*Block {
+	tasks := []*Block{}
+	for _, b := range d.Blocks {
+		if b.Kind == BlockTask {
+			tasks = append(tasks, b)
+		}
+	}
+	return tasks
+}
+
+// InlineTags returns the names of the body's #tags in the order they first
+// appear, ignoring case for duplicates
+func (d *Document) InlineTags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range d.Tags {
+		if key := strings.ToLower(tag.Name); !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag.Name)
+		}
+	}
+	return tags
+}
+
+// AllTags returns the tags in the note's tags property followed by its
+// inline tags, without the # and ignoring case for duplicates
+func (d *Document) AllTags() []string {
+	var tags []string
+	switch v := d.Frontmatter["tags"].(type) {
+	case []interface{}:
+		for _, tag := range v {
+			tags = append(tags, strings.TrimPrefix(fmt.Sprint(tag), "#"))
+		}
+	case string:
+		for _, tag := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+			tags = append(tags, strings.TrimPrefix(tag, "#"))
+		}
+	}
+
+	var all []string
+	seen := make(map[string]bool)
+	for _, tag := range append(tags, d.InlineTags()...) {
+		if key := strings.ToLower(tag); tag != "" && !seen[key] {
+			seen[key] = true
+			all = append(all, tag)
+		}
+	}
+	return all
+}
+
+// ResolveLink finds the note a link in notePath refers to. Like Obsidian,
+// an ambiguous wikilink resolves to the shallowest candidate. Links to
+// URLs, attachments and headings within the note resolve to nothing.
+func (n *NoteResolver) ResolveLink(link Link, notePath string) (string, bool) {
+	if !link.Markdown {
+		if link.Target == "" {
+			return "", false
+		}
+		return resolveLink(n, link.Target, notePath)
+	}
+	parsed, ok := parseMarkdownTarget(link.dest)
+	if !ok || parsed.path == "" {
+		return "", false
+	}
+	resolved, _ := resolveMarkdownLink(n, parsed.path, notePath)
+	return resolved, resolved != ""
+}
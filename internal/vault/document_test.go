@@ -0,0 +1,227 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDocumentBlocks(t *testing.T) {
+	type block struct {
+		Kind BlockKind
+		Text string
+		ID   string
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []block
+	}{
+		{
+			name:    "paragraphs",
+			content: "One\ntwo ^p1\n\nThree\n",
+			want:    []block{{BlockParagraph, "One\ntwo", "p1"}, {BlockParagraph, "Three", ""}},
+		},
+		{
+			name:    "marker on its own line",
+			content: "Text\n^own\n",
+			want:    []block{{BlockParagraph, "Text", "own"}},
+		},
+		{
+			name:    "list items and tasks",
+			content: "- one ^li\n  - two\n- [ ] open\n- [x] done ^t\n",
+			want: []block{
+				{BlockListItem, "one", "li"},
+				{BlockListItem, "two", ""},
+				{BlockTask, "open", ""},
+				{BlockTask, "done", "t"},
+			},
+		},
+		{
+			name:    "callout and quote",
+			content: "> [!note]- Title\n> body\n\n> quoted\n",
+			want:    []block{{BlockCallout, "body", ""}, {BlockQuote, "quoted", ""}},
+		},
+		{
+			name:    "code",
+			content: "```go\nx := 1 ^notid\n```\n",
+			want:    []block{{BlockCode, "x := 1 ^notid", ""}},
+		},
+		{
+			name:    "longer fence holds shorter fences",
+			content: "````\n```\n# not a heading\n```\n````\nAfter\n",
+			want:    []block{{BlockCode, "```\n# not a heading\n```", ""}, {BlockParagraph, "After", ""}},
+		},
+		{
+			name:    "closing fence must be as long",
+			content: "````\n```\nstill code\n````\n",
+			want:    []block{{BlockCode, "```\nstill code", ""}},
+		},
+		{
+			name:    "tilde fence",
+			content: "~~~\n```\n~~~\n",
+			want:    []block{{BlockCode, "```", ""}},
+		},
+		{
+			name:    "frontmatter is not a block",
+			content: "---\na: 1\n---\nBody\n",
+			want:    []block{{BlockParagraph, "Body", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []block
+			for _, b := range ParseDocument(tt.content).Blocks {
+				got = append(got, block{b.Kind, b.Text, b.ID})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDocumentBlockDetails(t *testing.T) {
+	doc := ParseDocument("> [!warning]+ Careful\n> text\n\n- [/] started\n\t- [ ] nested\n\n```python\nprint()\n```\n")
+	if len(doc.Blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(doc.Blocks))
+	}
+	callout, task, nested, code := doc.Blocks[0], doc.Blocks[1], doc.Blocks[2], doc.Blocks[3]
+	if callout.Callout != "warning" || callout.Title != "Careful" || callout.Fold != "+" {
+		t.Errorf("callout %q %q %q, want warning Careful +", callout.Callout, callout.Title, callout.Fold)
+	}
+	if task.Status != "/" || task.Done() {
+		t.Errorf("task status %q, done %v, want / and not done", task.Status, task.Done())
+	}
+	if nested.Indent != 4 {
+		t.Errorf("nested task indent %d, want 4", nested.Indent)
+	}
+	if code.Language != "python" || code.Line != 6 || code.EndLine != 8 {
+		t.Errorf("code block %q on lines %d-%d, want python on 6-8", code.Language, code.Line, code.EndLine)
+	}
+}
+
+func TestParseDocumentHeadings(t *testing.T) {
+	content := "---\ntitle: x\n---\n# Top\nintro\n## Sub\n```\n# in code\n```\n### Deep\n## Next\n#notaheading\n"
+	doc := ParseDocument(content)
+
+	type heading struct {
+		Level int
+		Text  string
+		Line  int
+	}
+	var got []heading
+	for _, h := range doc.Headings {
+		got = append(got, heading{h.Level, h.Text, h.Line})
+	}
+	want := []heading{{1, "Top", 3}, {2, "Sub", 5}, {3, "Deep", 9}, {2, "Next", 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if doc.Title() != "Top" {
+		t.Errorf("title %q, want Top", doc.Title())
+	}
+	if len(doc.Outline) != 1 || len(doc.Outline[0].Children) != 2 {
+		t.Errorf("outline doesn't nest Sub and Next under Top")
+	}
+	sub := doc.Heading("## sub")
+	if sub == nil || content[sub.Start:sub.End] != "## Sub\n```\n# in code\n```\n### Deep\n" {
+		t.Errorf("section of Sub is wrong: %+v", sub)
+	}
+	if h := doc.Heading("Next"); h == nil || h.End != len(content) {
+		t.Errorf("last section doesn't end the note: %+v", h)
+	}
+	if doc.Heading("# Sub") != nil {
+		t.Errorf("found Sub at the wrong level")
+	}
+}
+
+func TestParseDocumentLinks(t *testing.T) {
+	// Wikilinks come before markdown links on each line
+	content := "---\nup: \"[[Parent]]\"\n---\n" +
+		"[[Note#Head|shown]] ![[Image.png]] [text](Other%20Note.md#^b) [[#Local]]\n" +
+		"`[[Code]]` ``a `[[Code]]` b`` [[After Code]]\n" +
+		"````\n```\n[[Fenced]]\n```\n````\n"
+	doc := ParseDocument(content)
+
+	type link struct {
+		Target, Subpath, Display  string
+		Embed, Markdown, Property bool
+	}
+	var got []link
+	for _, l := range doc.Links {
+		got = append(got, link{l.Target, l.Subpath, l.Display, l.Embed, l.Markdown, l.Property})
+		if !l.Property && content[l.Start] != '[' && content[l.Start] != '!' {
+			t.Errorf("link %q starts at %q", l.Target, content[l.Start:l.End])
+		}
+	}
+	want := []link{
+		{Target: "Parent", Property: true},
+		{Target: "Note", Subpath: "#Head", Display: "shown"},
+		{Target: "Image.png", Embed: true},
+		{Subpath: "#Local"},
+		{Target: "Other Note.md", Subpath: "#^b", Display: "text", Markdown: true},
+		{Target: "After Code"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseDocumentTags(t *testing.T) {
+	content := "---\ntags: [Project, a/b]\n---\n# Heading #inheading\n#start middle#no #project `#code` [[Note#nottag]] #2024 #y2024\n```\n#fenced\n```\n"
+	doc := ParseDocument(content)
+	if got, want := doc.InlineTags(), []string{"inheading", "start", "project", "y2024"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("inline tags %v, want %v", got, want)
+	}
+	if got, want := doc.AllTags(), []string{"Project", "a/b", "inheading", "start", "y2024"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all tags %v, want %v", got, want)
+	}
+}
+
+func TestParseDocumentFrontmatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		bodyStart int
+		props     int
+	}{
+		{"none", "Body\n", 0, 0},
+		{"properties", "---\na: 1\n---\nBody\n", 13, 1},
+		{"crlf", "---\r\na: 1\r\n---\r\nBody\r\n", 16, 1},
+		{"empty", "---\n---\nBody\n", 8, 0},
+		{"invalid yaml", "---\na: [\n---\nBody\n", 13, 0},
+		{"not at the start", "\n---\na: 1\n---\n", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ParseDocument(tt.content)
+			if doc.BodyStart != tt.bodyStart {
+				t.Errorf("body starts at %d, want %d", doc.BodyStart, tt.bodyStart)
+			}
+			if len(doc.Frontmatter) != tt.props {
+				t.Errorf("frontmatter %v, want %d properties", doc.Frontmatter, tt.props)
+			}
+		})
+	}
+}
+
+func TestClosesFence(t *testing.T) {
+	tests := []struct {
+		line, fence string
+		want        bool
+	}{
+		{"```", "```", true},
+		{"````", "```", true},
+		{"```", "````", false},
+		{"   ```  ", "```", true},
+		{"```go", "```", false},
+		{"~~~", "```", false},
+		{"    ```", "```", false},
+	}
+	for _, tt := range tests {
+		if got := closesFence(tt.line, tt.fence); got != tt.want {
+			t.Errorf("closesFence(%q, %q) = %v, want %v", tt.line, tt.fence, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/diff"
+)
+
+// DryRun returns a copy of the writer that works out the changes its calls
+// would make without writing, moving or deleting anything. Planned returns
+// the changes so far.
+func (w *Writer) DryRun() *Writer {
+	c := *w
+	c.op = nil
+	c.plan = &Operation{Source: w.source, Action: "dry-run"}
+	return &c
+}
+
+// IsDryRun reports whether the writer was returned by DryRun
+func (w *Writer) IsDryRun() bool {
+	return w.plan != nil
+}
+
+// Planned returns the changes a dry run writer would have made, one per
+// file, leaving out files that would end up unchanged
+func (w *Writer) Planned() []FileChange {
+	changes := []FileChange{}
+	if w.plan == nil {
+		return changes
+	}
+	for _, change := range w.plan.Changes {
+		if !sameContent(change.Before, change.After) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// readFile reads a file as the dry run so far would have left it, so later
+// calls build on earlier ones. Outside dry runs it reads the file.
+func (w *Writer) readFile(fullPath string) ([]byte, error) {
+	if w.plan != nil {
+		if relPath, err := filepath.Rel(w.vaultPath, fullPath); err == nil {
+			for _, change := range w.plan.Changes {
+				if change.Path != relPath {
+					continue
+				}
+				if change.After == nil {
+					return nil, &os.PathError{Op: "open", Path: fullPath, Err: os.ErrNotExist}
+				}
+				return []byte(*change.After), nil
+			}
+		}
+	}
+	return os.ReadFile(fullPath)
+}
+
+// Kind describes the change as created, modified or deleted
+func (c FileChange) Kind() string {
+	switch {
+	case c.Before == nil:
+		return "created"
+	case c.After == nil:
+		return "deleted"
+	}
+	return "modified"
+}
+
+// Diff returns the change as a unified diff, against /dev/null for created
+// and deleted files
+func (c FileChange) Diff() string {
+	path := filepath.ToSlash(c.Path)
+	from, to := "a/"+path, "b/"+path
+	var before, after string
+	if c.Before == nil {
+		from = "/dev/null"
+	} else {
+		before = *c.Before
+	}
+	if c.After == nil {
+		to = "/dev/null"
+	} else {
+		after = *c.After
+	}
+	return diff.Unified(from, to, before, after)
+}
@@ -0,0 +1,320 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrHeadingNotFound is returned when a note has no heading matching the one given
+var ErrHeadingNotFound = errors.New("heading not found")
+
+// ErrBlockNotFound is returned when a note has no block with the ^id given
+var ErrBlockNotFound = errors.New("block not found")
+
+var (
+	headingRegex  = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	fenceRegex    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	listItemRegex = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+	blockIDRegex  = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+)
+
+// InsertUnderHeading inserts text as lines at the end of a heading's section,
+// which runs until the next heading of the same or a higher level. The
+// heading may be given with its level ("## Log") or without ("Log"); if the
+// note has no such heading, it is added at the end of the note.
+func (w *Writer) InsertUnderHeading(name, heading, text, expectedVersion string) (version string, err error) {
+	w, done := w.begin("edit")
+	defer done(&err)
+
+	return w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		return insertUnderHeading(content, heading, text), nil
+	})
+}
+
+// ReplaceSection replaces everything between a heading and the next heading
+// of the same or a higher level, subheadings included. The heading line is kept.
+func (w *Writer) ReplaceSection(name, heading, text, expectedVersion string) (version string, err error) {
+	w, done := w.begin("edit")
+	defer done(&err)
+
+	return w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		return replaceSection(content, heading, text)
+	})
+}
+
+// PrependToNote inserts text as its own paragraph at the start of the body,
+// after any frontmatter
+func (w *Writer) PrependToNote(name, text, expectedVersion string) (version string, err error) {
+	w, done := w.begin("edit")
+	defer done(&err)
+
+	return w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		return prependBlock(content, text), nil
+	})
+}
+
+// ReplaceBlock replaces the paragraph, list item or other block marked with
+// ^blockID. The marker is kept so links to the block still work.
+func (w *Writer) ReplaceBlock(name, blockID, text, expectedVersion string) (version string, err error) {
+	w, done := w.begin("edit")
+	defer done(&err)
+
+	return w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		return replaceBlock(content, blockID, text)
+	})
+}
+
+// ReplaceInNote replaces every occurrence of find in a note, either literally
+// or as a regular expression whose replacement may use $1-style groups. It
+// returns the note's version and the number of replacements made.
+func (w *Writer) ReplaceInNote(name, find, replace string, regex bool, expectedVersion string) (version string, count int, err error) {
+	w, done := w.begin("edit")
+	defer done(&err)
+
+	version, err = w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		var err error
+		content, count, err = replaceText(content, find, replace, regex)
+		return content, err
+	})
+	return version, count, err
+}
+
+// rewriteNote applies edit to an existing note and writes the result,
+// returning the note's new version. Unchanged notes are not rewritten.
+func (w *Writer) rewriteNote(name, expectedVersion string, edit func(content string) (string, error)) (string, error) {
+	fullPath, relPath, err := w.resolveExisting(name)
+	if err != nil {
+		return "", err
+	}
+	if err := w.policy.CheckWrite(relPath); err != nil {
+		return "", err
+	}
+	if err := w.lock(fullPath); err != nil {
+		return "", err
+	}
+
+	content, err := w.readFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read note: %w", err)
+	}
+	if err := checkVersion(relPath, content, expectedVersion); err != nil {
+		return "", err
+	}
+
+	newContent, err := edit(string(content))
+	if err != nil {
+		return "", err
+	}
+	if newContent == string(content) {
+		return ContentVersion(content), nil
+	}
+
+	if err := w.writeFile(fullPath, []byte(newContent)); err != nil {
+		return "", fmt.Errorf("failed to write note: %w", err)
+	}
+	return ContentVersion([]byte(newContent)), nil
+}
+
+// noteLines is a note split into lines for editing, with the document
+// parsed from it. The line ending of the note is kept so edits to CRLF
+// notes stay CRLF.
+type noteLines struct {
+	lines     []string
+	newline   string
+	bodyStart int       // index of the first line after the frontmatter
+	doc       *Document // the note as split, before any edits
+}
+
+func splitNote(content string) *noteLines {
+	n := &noteLines{newline: "\n", doc: ParseDocument(content)}
+	if strings.Contains(content, "\r\n") {
+		n.newline = "\r\n"
+	}
+	// Lines are numbered as the document numbers them, whatever their ending
+	if trimmed := strings.TrimSuffix(strings.TrimSuffix(content, "\n"), "\r"); trimmed != "" {
+		n.lines = strings.Split(trimmed, "\n")
+		for i, line := range n.lines {
+			n.lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+
+	n.bodyStart = strings.Count(content[:n.doc.BodyStart], "\n")
+	if n.doc.BodyStart > 0 && content[n.doc.BodyStart-1] != '\n' {
+		n.bodyStart++
+	}
+	return n
+}
+
+// String joins the lines back into a note ending with a newline
+func (n *noteLines) String() string {
+	if len(n.lines) == 0 {
+		return ""
+	}
+	return strings.Join(n.lines, n.newline) + n.newline
+}
+
+// insert puts lines before index i
+func (n *noteLines) insert(i int, lines ...string) {
+	n.replace(i, i, lines...)
+}
+
+// replace swaps the lines in [start, end) for lines
+func (n *noteLines) replace(start, end int, lines ...string) {
+	updated := make([]string, 0, len(n.lines)-(end-start)+len(lines))
+	updated = append(updated, n.lines[:start]...)
+	updated = append(updated, lines...)
+	n.lines = append(updated, n.lines[end:]...)
+}
+
+// isBlank reports whether line i is empty or whitespace
+func (n *noteLines) isBlank(i int) bool {
+	return strings.TrimSpace(n.lines[i]) == ""
+}
+
+// section finds the first heading matching spec and returns its line and
+// the end (exclusive) of its section
+func (n *noteLines) section(spec string) (start, end int, found bool) {
+	h := n.doc.Heading(spec)
+	if h == nil {
+		return 0, 0, false
+	}
+	end = len(n.lines)
+	for _, next := range n.doc.Headings {
+		if next.Line > h.Line && next.Level <= h.Level {
+			end = next.Line
+			break
+		}
+	}
+	return h.Line, end, true
+}
+
+// parseHeadingSpec splits "## Log" into its level and text; level is 0
+// when the spec has no leading #s and any level matches
+func parseHeadingSpec(spec string) (int, string) {
+	spec = strings.TrimSpace(spec)
+	if m := headingRegex.FindStringSubmatch(spec); m != nil {
+		return len(m[1]), strings.TrimSpace(m[2])
+	}
+	return 0, spec
+}
+
+// textLines splits inserted text into lines, dropping trailing newlines
+func textLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	return strings.Split(text, "\n")
+}
+
+// trimTrailingBlank returns the index after the last non-blank line in [from, end)
+func (n *noteLines) trimTrailingBlank(from, end int) int {
+	for end > from && n.isBlank(end-1) {
+		end--
+	}
+	return end
+}
+
+func insertUnderHeading(content, spec, text string) string {
+	n := splitNote(content)
+	start, end, found := n.section(spec)
+	if !found {
+		level, title := parseHeadingSpec(spec)
+		if level == 0 {
+			level = 2
+		}
+		at := n.trimTrailingBlank(n.bodyStart, len(n.lines))
+		lines := append([]string{strings.Repeat("#", level) + " " + title}, textLines(text)...)
+		if at > n.bodyStart {
+			lines = append([]string{""}, lines...)
+		}
+		n.replace(at, len(n.lines), lines...)
+		return n.String()
+	}
+
+	n.insert(n.trimTrailingBlank(start+1, end), textLines(text)...)
+	return n.String()
+}
+
+func replaceSection(content, spec, text string) (string, error) {
+	n := splitNote(content)
+	start, end, found := n.section(spec)
+	if !found {
+		return "", fmt.Errorf("%w: %q", ErrHeadingNotFound, spec)
+	}
+
+	var lines []string
+	if strings.TrimSpace(text) != "" {
+		lines = textLines(text)
+	}
+	// Keep a blank line before the next heading
+	if end < len(n.lines) {
+		lines = append(lines, "")
+	}
+	n.replace(start+1, end, lines...)
+	return n.String(), nil
+}
+
+func prependBlock(content, text string) string {
+	n := splitNote(content)
+	at := n.bodyStart
+	for at < len(n.lines) && n.isBlank(at) {
+		at++
+	}
+	lines := textLines(text)
+	if at < len(n.lines) {
+		lines = append(lines, "")
+	}
+	n.insert(at, lines...)
+	return n.String()
+}
+
+func replaceBlock(content, blockID, text string) (string, error) {
+	blockID = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(blockID), "#"), "^")
+	if !blockIDRegex.MatchString(blockID) {
+		return "", fmt.Errorf("invalid block id %q, use letters, numbers and dashes", blockID)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("replacement text for block ^%s is empty", blockID)
+	}
+	marker := "^" + blockID
+	lines := textLines(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), marker)))
+
+	n := splitNote(content)
+	b := n.doc.Block(blockID)
+	if b == nil {
+		return "", fmt.Errorf("%w: %s", ErrBlockNotFound, marker)
+	}
+	last := n.lines[b.EndLine]
+	if m := blockMarkerRegex.FindStringSubmatch(last); m == nil || m[1] != blockID {
+		// A marker on its own line labels the block above it and stays put
+		n.replace(b.Line, b.EndLine+1, lines...)
+		return n.String(), nil
+	}
+	if b.Kind == BlockListItem || b.Kind == BlockTask {
+		// List items are blocks of their own; keep the item's indentation
+		first := n.lines[b.Line]
+		indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+		for j := range lines {
+			lines[j] = indent + lines[j]
+		}
+	}
+	lines[len(lines)-1] += " " + marker
+	n.replace(b.Line, b.EndLine+1, lines...)
+	return n.String(), nil
+}
+
+func replaceText(content, find, replace string, regex bool) (string, int, error) {
+	if find == "" {
+		return "", 0, fmt.Errorf("text to find is empty")
+	}
+	if !regex {
+		return strings.ReplaceAll(content, find, replace), strings.Count(content, find), nil
+	}
+
+	re, err := regexp.Compile(find)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	count := len(re.FindAllStringIndex(content, -1))
+	return re.ReplaceAllString(content, replace), count, nil
+}
@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInsertUnderHeading(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		spec    string
+		want    string
+	}{
+		{
+			name:    "end of section",
+			content: "# A\none\n\n# B\ntwo\n",
+			spec:    "A",
+			want:    "# A\none\nnew\n\n# B\ntwo\n",
+		},
+		{
+			name:    "subsections belong to the section",
+			content: "# A\none\n## Sub\nsub\n# B\n",
+			spec:    "# A",
+			want:    "# A\none\n## Sub\nsub\nnew\n# B\n",
+		},
+		{
+			name:    "headings in fences are ignored",
+			content: "# A\n````\n```\n# B\n```\n````\n# B\ntwo\n",
+			spec:    "B",
+			want:    "# A\n````\n```\n# B\n```\n````\n# B\ntwo\nnew\n",
+		},
+		{
+			name:    "missing heading is added",
+			content: "---\na: 1\n---\nText\n",
+			spec:    "Log",
+			want:    "---\na: 1\n---\nText\n\n## Log\nnew\n",
+		},
+		{
+			name:    "crlf",
+			content: "# A\r\none\r\n",
+			spec:    "A",
+			want:    "# A\r\none\r\nnew\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := insertUnderHeading(tt.content, tt.spec, "new"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	content := "---\ntitle: x\n---\n# A\nold\n```\n# not a heading\n```\n\n# B\nkeep\n"
+	got, err := replaceSection(content, "A", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\ntitle: x\n---\n# A\nnew\n\n# B\nkeep\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := replaceSection(content, "not a heading", "new"); !errors.Is(err, ErrHeadingNotFound) {
+		t.Errorf("got %v for a heading in code, want ErrHeadingNotFound", err)
+	}
+}
+
+func TestReplaceBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		id      string
+		text    string
+		want    string
+		err     error
+	}{
+		{
+			name:    "paragraph",
+			content: "Before\n\nOld text\nacross lines ^p\n\nAfter\n",
+			id:      "p",
+			text:    "New text",
+			want:    "Before\n\nNew text ^p\n\nAfter\n",
+		},
+		{
+			name:    "marker on its own line stays",
+			content: "Old text\n^p\n",
+			id:      "^p",
+			text:    "New text",
+			want:    "New text\n^p\n",
+		},
+		{
+			name:    "nested list item keeps its indentation",
+			content: "- one\n  - two ^li\n- three\n",
+			id:      "li",
+			text:    "- 2",
+			want:    "- one\n  - 2 ^li\n- three\n",
+		},
+		{
+			name:    "task",
+			content: "- [ ] todo ^t\n",
+			id:      "t",
+			text:    "- [x] done ^t",
+			want:    "- [x] done ^t\n",
+		},
+		{
+			name:    "markers in code are not blocks",
+			content: "````\n```\ncode ^p\n```\n````\n",
+			id:      "p",
+			text:    "New",
+			err:     ErrBlockNotFound,
+		},
+		{
+			name:    "missing",
+			content: "Text\n",
+			id:      "p",
+			text:    "New",
+			err:     ErrBlockNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replaceBlock(tt.content, tt.id, tt.text)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %q, %v, want %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
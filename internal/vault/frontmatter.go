@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 	"strings"
@@ -11,8 +12,9 @@ import (
 // Frontmatter represents the YAML frontmatter of a markdown file
 type Frontmatter map[string]interface{}
 
-// frontmatterRegex matches YAML frontmatter at the start of a file
-var frontmatterRegex = regexp.MustCompile(`(?s)^---\n(.*?)\n---\n?`)
+// frontmatterRegex matches YAML frontmatter at the start of a file, which
+// may be empty and may use CRLF line endings
+var frontmatterRegex = regexp.MustCompile(`(?s)^---[ \t]*\r?\n(?:(.*?)\r?\n)?---[ \t]*(?:\r?\n|$)`)
 
 // ParseFrontmatter extracts frontmatter and content from markdown
 func ParseFrontmatter(content string) (Frontmatter, string, error) {
@@ -26,9 +28,10 @@ func ParseFrontmatter(content string) (Frontmatter, string, error) {
 	if err := yaml.Unmarshal([]byte(matches[1]), &fm); err != nil {
 		return nil, "", fmt.Errorf("failed to parse frontmatter: %w", err)
 	}
+	normalizeDates(fm)
 
 	// Remove frontmatter from content
-	body := frontmatterRegex.ReplaceAllString(content, "")
+	body := content[len(matches[0]):]
 	return fm, body, nil
 }
 
@@ -38,7 +41,7 @@ func SerializeFrontmatter(fm Frontmatter) (string, error) {
 		return "", nil
 	}
 
-	data, err := yaml.Marshal(fm)
+	data, err := marshalYAML(fm)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize frontmatter: %w", err)
 	}
@@ -73,3 +76,18 @@ func MergeFrontmatter(existing, updates Frontmatter) Frontmatter {
 
 	return existing
 }
+
+// marshalYAML encodes v the way Obsidian writes properties, with list items
+// indented by two spaces
+func marshalYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,369 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontmatterEditor edits a note's frontmatter in place. A changed key has
+// only its own lines rewritten, so the order, comments, quoting and
+// formatting of every other key, and the note's body, stay byte-for-byte
+// the same.
+type FrontmatterEditor struct {
+	open    string   // opening delimiter as written, empty if the note has no frontmatter
+	lines   []string // YAML lines between the delimiters, without line endings
+	close   string   // closing delimiter as written
+	body    string
+	newline string
+	node    *yaml.Node // mapping parsed from lines
+	changed bool
+}
+
+// EditFrontmatter parses a note's frontmatter for editing. Notes without
+// frontmatter get a block when the first key is set.
+func EditFrontmatter(content string) (*FrontmatterEditor, error) {
+	e := &FrontmatterEditor{body: content, newline: "\n"}
+	if m := frontmatterRegex.FindStringSubmatchIndex(content); m != nil {
+		e.open = content[:strings.Index(content, "\n")+1]
+		e.body = content[m[1]:]
+		if m[2] >= 0 {
+			e.lines = strings.Split(content[m[2]:m[3]], "\n")
+			e.close = content[m[3]:m[1]]
+			e.close = e.close[strings.Index(e.close, "\n")+1:]
+		} else {
+			e.close = content[len(e.open):m[1]]
+		}
+		for i, line := range e.lines {
+			e.lines[i] = strings.TrimSuffix(line, "\r")
+		}
+		if strings.HasSuffix(e.open, "\r\n") {
+			e.newline = "\r\n"
+		}
+	} else if strings.Contains(content, "\r\n") {
+		e.newline = "\r\n"
+	}
+
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	if e.node.Style&yaml.FlowStyle != 0 {
+		// A one-line {a: 1, b: 2} mapping has no per-key lines to edit
+		e.node.Style = 0
+		if err := e.render(0, len(e.lines), e.node); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// parse rebuilds the mapping node from the frontmatter lines
+func (e *FrontmatterEditor) parse() error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(e.lines, "\n")), &doc); err != nil {
+		return fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		e.node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		return nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to parse frontmatter: not a list of properties")
+	}
+	e.node = doc.Content[0]
+	return nil
+}
+
+// Keys lists the properties in the order they are written
+func (e *FrontmatterEditor) Keys() []string {
+	keys := make([]string, 0, len(e.node.Content)/2)
+	for i := 0; i < len(e.node.Content); i += 2 {
+		keys = append(keys, e.node.Content[i].Value)
+	}
+	return keys
+}
+
+// Get returns a property's value, decoded as ParseFrontmatter would
+func (e *FrontmatterEditor) Get(key string) (interface{}, bool) {
+	i := e.find(key)
+	if i < 0 {
+		return nil, false
+	}
+	var value interface{}
+	if err := e.node.Content[i+1].Decode(&value); err != nil {
+		return nil, false
+	}
+	return normalizeDates(value), true
+}
+
+// Set adds a property or replaces its value. A replaced value keeps its
+// quoting, flow style and trailing comment; setting the current value,
+// including a date given as its YYYY-MM-DD text, changes nothing.
+func (e *FrontmatterEditor) Set(key string, value interface{}) error {
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	i := e.find(key)
+	if i < 0 {
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		at := 0
+		if n := len(e.node.Content); n > 0 {
+			_, at = e.keyRange(n - 2)
+		}
+		return e.render(at, at, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, valueNode}})
+	}
+
+	if current, ok := e.Get(key); ok {
+		var updated interface{}
+		if err := valueNode.Decode(&updated); err == nil && sameProperty(current, normalizeDates(updated)) {
+			return nil
+		}
+	}
+
+	oldKey, oldValue := e.node.Content[i], e.node.Content[i+1]
+	if anchor := e.anchorInUse(oldValue); anchor != "" {
+		return fmt.Errorf("can't change %s: its anchor &%s is used elsewhere in the frontmatter", key, anchor)
+	}
+	keepStyle(oldValue, valueNode)
+	valueNode.LineComment = oldValue.LineComment
+	keyNode := &yaml.Node{Kind: oldKey.Kind, Tag: oldKey.Tag, Style: oldKey.Style, Value: oldKey.Value, LineComment: oldKey.LineComment}
+
+	start, end := e.keyRange(i)
+	return e.render(start, end, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, valueNode}})
+}
+
+// Rename renames a property in place, leaving its value exactly as
+// written, and reports whether it was there
+func (e *FrontmatterEditor) Rename(from, to string) (bool, error) {
+	i := e.find(from)
+	if i < 0 {
+		return false, nil
+	}
+	if from == to {
+		return true, nil
+	}
+	if e.find(to) >= 0 {
+		return false, fmt.Errorf("property %q already exists", to)
+	}
+
+	key := e.node.Content[i]
+	line := e.lines[key.Line-1]
+	col := key.Column - 1
+	written := len(key.Value)
+	if key.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+		written = quotedLength(line[col:])
+	}
+	data, err := marshalYAML(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: to})
+	if err != nil {
+		return false, fmt.Errorf("failed to serialize frontmatter: %w", err)
+	}
+	e.lines[key.Line-1] = line[:col] + strings.TrimSuffix(string(data), "\n") + line[col+written:]
+	e.changed = true
+	return true, e.parse()
+}
+
+// quotedLength returns the length of the quoted YAML scalar s starts with
+func quotedLength(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Delete removes a property, and the comment lines directly above it,
+// reporting whether it was there
+func (e *FrontmatterEditor) Delete(key string) (bool, error) {
+	i := e.find(key)
+	if i < 0 {
+		return false, nil
+	}
+	for _, node := range e.node.Content[i : i+2] {
+		if anchor := e.anchorInUse(node); anchor != "" {
+			return false, fmt.Errorf("can't delete %s: its anchor &%s is used elsewhere in the frontmatter", key, anchor)
+		}
+	}
+	start, end := e.keyRange(i)
+	if e.node.Content[i].HeadComment != "" {
+		for start > 0 && isComment(e.lines[start-1]) {
+			start--
+		}
+	}
+	if end == len(e.lines) {
+		// Don't leave blank lines at the end of the block
+		for start > 0 && strings.TrimSpace(e.lines[start-1]) == "" {
+			start--
+		}
+	}
+	e.lines = append(e.lines[:start], e.lines[end:]...)
+	e.changed = true
+	return true, e.parse()
+}
+
+// String returns the note with the edited frontmatter. A block left
+// without properties by the edits is removed.
+func (e *FrontmatterEditor) String() string {
+	empty := true
+	for _, line := range e.lines {
+		if strings.TrimSpace(line) != "" {
+			empty = false
+			break
+		}
+	}
+	switch {
+	case e.open == "" && empty, e.changed && empty:
+		return e.body
+	case e.open == "":
+		return "---" + e.newline + strings.Join(e.lines, e.newline) + e.newline + "---" + e.newline + e.body
+	case len(e.lines) == 0:
+		return e.open + e.close + e.body
+	}
+	return e.open + strings.Join(e.lines, e.newline) + e.newline + e.close + e.body
+}
+
+// find returns the index of a key's node in the mapping, or -1
+func (e *FrontmatterEditor) find(key string) int {
+	for i := 0; i < len(e.node.Content); i += 2 {
+		if e.node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// keyRange returns the lines [start, end) holding the key at node index i
+// and its value. Blank lines and comments before the next key belong to it.
+func (e *FrontmatterEditor) keyRange(i int) (int, int) {
+	start := e.node.Content[i].Line - 1
+	end := len(e.lines)
+	if i+2 < len(e.node.Content) {
+		end = e.node.Content[i+2].Line - 1
+	}
+	for end > start+1 && (strings.TrimSpace(e.lines[end-1]) == "" || isComment(e.lines[end-1])) {
+		end--
+	}
+	return start, end
+}
+
+// isComment reports whether a frontmatter line is a comment
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "#")
+}
+
+// anchorInUse returns an anchor set on node or within it that an alias
+// elsewhere in the frontmatter refers to, or ""
+func (e *FrontmatterEditor) anchorInUse(node *yaml.Node) string {
+	anchored := make(map[*yaml.Node]bool)
+	var collect func(n *yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n.Anchor != "" {
+			anchored[n] = true
+		}
+		for _, child := range n.Content {
+			collect(child)
+		}
+	}
+	collect(node)
+	if len(anchored) == 0 {
+		return ""
+	}
+
+	var find func(n *yaml.Node) string
+	find = func(n *yaml.Node) string {
+		if n == node {
+			return ""
+		}
+		if n.Kind == yaml.AliasNode && anchored[n.Alias] {
+			return n.Alias.Anchor
+		}
+		for _, child := range n.Content {
+			if anchor := find(child); anchor != "" {
+				return anchor
+			}
+		}
+		return ""
+	}
+	return find(e.node)
+}
+
+// render replaces lines [start, end) with the YAML for a mapping node,
+// indented like the frontmatter's own keys
+func (e *FrontmatterEditor) render(start, end int, mapping *yaml.Node) error {
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return fmt.Errorf("failed to serialize frontmatter: %w", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if e.node.Column > 1 {
+		indent := strings.Repeat(" ", e.node.Column-1)
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = indent + line
+			}
+		}
+	}
+
+	updated := make([]string, 0, len(e.lines)-(end-start)+len(lines))
+	updated = append(updated, e.lines[:start]...)
+	updated = append(updated, lines...)
+	e.lines = append(updated, e.lines[end:]...)
+	e.changed = true
+	return e.parse()
+}
+
+// sameProperty reports whether two decoded property values are the same as
+// the tools see them, so a date equals its YYYY-MM-DD text
+func sameProperty(a, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+// keepStyle carries a value's quoting or flow style over to its replacement
+func keepStyle(old, updated *yaml.Node) {
+	if old.Kind != updated.Kind {
+		return
+	}
+	switch updated.Kind {
+	case yaml.ScalarNode:
+		if old.Tag == "!!str" && updated.Tag == "!!str" {
+			updated.Style = old.Style & (yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle)
+		}
+		// A date given as text stays an unquoted date
+		plain := &yaml.Node{Kind: yaml.ScalarNode, Value: updated.Value}
+		if old.Tag == "!!timestamp" && updated.Tag == "!!str" && plain.ShortTag() == "!!timestamp" {
+			updated.Tag = "!!timestamp"
+			updated.Style = 0
+		}
+	case yaml.SequenceNode:
+		updated.Style = old.Style & yaml.FlowStyle
+		// Items keep their quoting, and new items are quoted like the last one
+		if len(old.Content) == 0 {
+			break
+		}
+		for _, item := range updated.Content {
+			like := old.Content[len(old.Content)-1]
+			for _, o := range old.Content {
+				if o.Kind == item.Kind && o.Value == item.Value {
+					like = o
+					break
+				}
+			}
+			keepStyle(like, item)
+		}
+	case yaml.MappingNode:
+		updated.Style = old.Style & yaml.FlowStyle
+	}
+}
@@ -0,0 +1,268 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFrontmatterEditorSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		value   interface{}
+		want    string
+	}{
+		{
+			name:    "adds a key after the last one",
+			content: "---\ntitle: Plan\n---\nBody\n",
+			key:     "status",
+			value:   "draft",
+			want:    "---\ntitle: Plan\nstatus: draft\n---\nBody\n",
+		},
+		{
+			name:    "creates frontmatter",
+			content: "Body\n",
+			key:     "status",
+			value:   "draft",
+			want:    "---\nstatus: draft\n---\nBody\n",
+		},
+		{
+			name:    "keeps other lines, quoting and comments",
+			content: "---\n# about\ntitle: 'Plan'  # name\nstatus: \"open\" # state\n---\n",
+			key:     "status",
+			value:   "done",
+			want:    "---\n# about\ntitle: 'Plan'  # name\nstatus: \"done\" # state\n---\n",
+		},
+		{
+			name:    "keeps flow lists",
+			content: "---\ntags: [a, b]\n---\n",
+			key:     "tags",
+			value:   []interface{}{"a", "b", "c"},
+			want:    "---\ntags: [a, b, c]\n---\n",
+		},
+		{
+			name:    "unchanged date given as text",
+			content: "---\ndate: 2024-01-02\n---\n",
+			key:     "date",
+			value:   "2024-01-02",
+			want:    "---\ndate: 2024-01-02\n---\n",
+		},
+		{
+			name:    "unchanged date given as a date",
+			content: "---\ndate: 2024-01-02 # due\n---\n",
+			key:     "date",
+			value:   mustDate(t, "2024-01-02"),
+			want:    "---\ndate: 2024-01-02 # due\n---\n",
+		},
+		{
+			name:    "new date given as text stays unquoted",
+			content: "---\ndate: 2024-01-02\n---\n",
+			key:     "date",
+			value:   "2024-03-04",
+			want:    "---\ndate: 2024-03-04\n---\n",
+		},
+		{
+			name:    "quoted date text stays quoted",
+			content: "---\ndate: \"2024-01-02\"\n---\n",
+			key:     "date",
+			value:   "2024-03-04",
+			want:    "---\ndate: \"2024-03-04\"\n---\n",
+		},
+		{
+			name:    "text replacing a date",
+			content: "---\ndate: 2024-01-02\n---\n",
+			key:     "date",
+			value:   "soon",
+			want:    "---\ndate: soon\n---\n",
+		},
+		{
+			name:    "indented mapping",
+			content: "---\n  a: 1\n  b: 2\n---\n",
+			key:     "b",
+			value:   3,
+			want:    "---\n  a: 1\n  b: 3\n---\n",
+		},
+		{
+			name:    "new key in indented mapping",
+			content: "---\n  a: 1\n---\n",
+			key:     "tags",
+			value:   []interface{}{"x"},
+			want:    "---\n  a: 1\n  tags:\n    - x\n---\n",
+		},
+		{
+			name:    "one-line flow mapping",
+			content: "---\n{a: 1, b: 2}\n---\n",
+			key:     "b",
+			value:   3,
+			want:    "---\na: 1\nb: 3\n---\n",
+		},
+		{
+			name:    "crlf",
+			content: "---\r\na: 1\r\n---\r\nBody\r\n",
+			key:     "b",
+			value:   2,
+			want:    "---\r\na: 1\r\nb: 2\r\n---\r\nBody\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := EditFrontmatter(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Set(tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontmatterEditorDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		want    string
+		found   bool
+		err     string
+	}{
+		{
+			name:    "removes key and its comment",
+			content: "---\na: 1\n# about b\nb: 2\nc: 3\n---\n",
+			key:     "b",
+			want:    "---\na: 1\nc: 3\n---\n",
+			found:   true,
+		},
+		{
+			name:    "removes block lists",
+			content: "---\ntags:\n  - a\n  - b\nc: 3\n---\n",
+			key:     "tags",
+			want:    "---\nc: 3\n---\n",
+			found:   true,
+		},
+		{
+			name:    "removes the block when empty",
+			content: "---\na: 1\n---\nBody\n",
+			key:     "a",
+			want:    "Body\n",
+			found:   true,
+		},
+		{
+			name:    "missing key",
+			content: "---\na: 1\n---\n",
+			key:     "b",
+			want:    "---\na: 1\n---\n",
+		},
+		{
+			name:    "indented mapping with comment",
+			content: "---\n  a: 1\n  # about b\n  b: 2\n---\n",
+			key:     "b",
+			want:    "---\n  a: 1\n---\n",
+			found:   true,
+		},
+		{
+			name:    "unused anchor",
+			content: "---\na: &x 1\nb: 2\n---\n",
+			key:     "a",
+			want:    "---\nb: 2\n---\n",
+			found:   true,
+		},
+		{
+			name:    "anchor used elsewhere",
+			content: "---\na: &x 1\nb: *x\n---\n",
+			key:     "a",
+			want:    "---\na: &x 1\nb: *x\n---\n",
+			err:     "anchor &x is used elsewhere",
+		},
+		{
+			name:    "alias",
+			content: "---\na: &x 1\nb: *x\n---\n",
+			key:     "b",
+			want:    "---\na: &x 1\n---\n",
+			found:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := EditFrontmatter(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, err := e.Delete(tt.key)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.found {
+				t.Errorf("found = %v, want %v", found, tt.found)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontmatterEditorSetAnchor(t *testing.T) {
+	e, err := EditFrontmatter("---\na: &x 1\nb: *x\n---\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Set("a", 2); err == nil || !strings.Contains(err.Error(), "anchor &x") {
+		t.Errorf("got error %v, want anchor error", err)
+	}
+	if err := e.Set("b", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.String(), "---\na: &x 1\nb: 2\n---\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFrontmatterEditorRename(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		from    string
+		to      string
+		want    string
+	}{
+		{"plain", "---\nstate: open # now\n---\n", "state", "status", "---\nstatus: open # now\n---\n"},
+		{"quoted", "---\n'state': open\n---\n", "state", "status", "---\nstatus: open\n---\n"},
+		{"indented", "---\n  state: open\n---\n", "state", "status", "---\n  status: open\n---\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := EditFrontmatter(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := e.Rename(tt.from, tt.to); err != nil || !ok {
+				t.Fatalf("Rename = %v, %v", ok, err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustDate(t *testing.T, s string) Date {
+	t.Helper()
+	value, err := ParsePropertyValue(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, ok := value.(Date)
+	if !ok {
+		t.Fatalf("%s parsed as %T, not a date", s, value)
+	}
+	return date
+}
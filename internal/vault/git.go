@@ -0,0 +1,391 @@
+package vault
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitMode says when writes are committed to the vault's git repository
+type GitMode string
+
+const (
+	GitOff GitMode = "off"
+	// GitPerOperation commits every operation on its own
+	GitPerOperation GitMode = "operation"
+	// GitPerSession commits the operations of a CLI command or an MCP
+	// session together when it ends
+	GitPerSession GitMode = "session"
+)
+
+// gitSourceTrailer marks commits made by a GitCommitter and names the
+// command or tool call that made the changes
+const gitSourceTrailer = "Agent-Source"
+
+var gitAuthorRegex = regexp.MustCompile(`^[^<>]+ <[^<>]*>$`)
+
+// ParseGitMode parses a git mode, "" meaning off
+func ParseGitMode(s string) (GitMode, error) {
+	switch m := GitMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "", "false":
+		return GitOff, nil
+	case GitOff, GitPerOperation, GitPerSession:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown git commit mode %q (use off, operation or session)", s)
+}
+
+// GitConfig configures committing writes to the vault's git repository
+type GitConfig struct {
+	Mode GitMode
+	// Author is the commit author as "Name <email>"; empty uses git's user
+	Author string
+}
+
+// Enabled reports whether writes are committed
+func (c GitConfig) Enabled() bool {
+	return c.Mode != "" && c.Mode != GitOff
+}
+
+// GitConfigFromEnv reads the git settings from environment variables. An
+// invalid mode is reported when the config is used.
+//
+//	OBSIDIAN_GIT_COMMIT=off|operation|session
+//	OBSIDIAN_GIT_AUTHOR="Agent <agent@example.com>"
+func GitConfigFromEnv() GitConfig {
+	return GitConfig{
+		Mode:   GitMode(os.Getenv("OBSIDIAN_GIT_COMMIT")),
+		Author: os.Getenv("OBSIDIAN_GIT_AUTHOR"),
+	}
+}
+
+// RegisterFlags adds the git settings as command line flags, defaulting to
+// the current values
+func (c *GitConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var((*gitModeFlag)(&c.Mode), "git-commit", "Commit vault changes to git: off, operation or session (one commit per command or MCP session)")
+	fs.StringVar(&c.Author, "git-author", c.Author, "Author of git commits as \"Name <email>\" (default: git's user)")
+}
+
+// gitModeFlag parses the --git-commit flag
+type gitModeFlag GitMode
+
+func (f *gitModeFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *gitModeFlag) Set(value string) error {
+	mode, err := ParseGitMode(value)
+	*f = gitModeFlag(mode)
+	return err
+}
+
+// GitRepo runs git in the work tree containing a vault
+type GitRepo struct {
+	root   string // top of the work tree
+	prefix string // the vault's folder in the work tree, "" or ending in /
+}
+
+// OpenGitRepo finds the git work tree containing a vault
+func OpenGitRepo(vaultPath string) (*GitRepo, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel", "--show-prefix")
+	cmd.Dir = vaultPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository: %w", vaultPath, gitError(err))
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	repo := &GitRepo{root: lines[0]}
+	if len(lines) > 1 {
+		repo.prefix = lines[1]
+	}
+	return repo, nil
+}
+
+// run runs git at the top of the work tree and returns its output.
+// Pathspecs are literal so note names are never taken as patterns.
+func (r *GitRepo) run(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"--literal-pathspecs", "-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = r.root
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w", args[0], gitError(err))
+	}
+	return string(out), nil
+}
+
+// gitError adds git's error output to a failed command's error
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return errors.New(msg)
+		}
+	}
+	return err
+}
+
+// gitCommitted reports whether a changed file belongs in agent commits.
+// Copies in the vault's .trash folder and the agent's own journal and lock
+// folders are left out, so a local delete commits as the note's removal.
+func gitCommitted(relPath string) bool {
+	top, _, _ := strings.Cut(filepath.ToSlash(relPath), "/")
+	return top != TrashFolder && top != JournalFolder && top != LockFolder
+}
+
+// Commit commits the files changed by ops, leaving other changes in the
+// work tree and index alone. Files git ignores, and those gitCommitted
+// leaves out, are skipped. It returns the new commit's hash, or "" when git
+// sees nothing to commit.
+func (r *GitRepo) Commit(ops []*Operation, author string) (string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		for _, path := range op.Paths() {
+			if !gitCommitted(path) {
+				continue
+			}
+			path = r.prefix + filepath.ToSlash(path)
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	// Only commit what git sees as changed; ignored files aren't listed
+	status, err := r.run(append([]string{"status", "--porcelain", "-z", "--no-renames", "--untracked-files=all", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+	var changed []string
+	for _, entry := range strings.Split(status, "\x00") {
+		if len(entry) > 3 {
+			changed = append(changed, entry[3:])
+		}
+	}
+	if len(changed) == 0 {
+		return "", nil
+	}
+
+	if _, err := r.run(append([]string{"add", "-A", "--"}, changed...)...); err != nil {
+		return "", err
+	}
+	args := []string{"commit", "-q", "-m", gitSubject(ops), "-m", gitBody(ops)}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	if _, err := r.run(append(append(args, "--"), changed...)...); err != nil {
+		return "", err
+	}
+	hash, err := r.run("rev-parse", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+// gitSubject summarizes operations as "<source>: <action> <notes>"
+func gitSubject(ops []*Operation) string {
+	// A session's tool calls share their first word, e.g. "mcp"
+	source := ops[0].Source
+	for _, op := range ops[1:] {
+		if op.Source != source {
+			source, _, _ = strings.Cut(source, " ")
+			if other, _, _ := strings.Cut(op.Source, " "); other != source {
+				source = ""
+			}
+		}
+	}
+	if source == "" {
+		source = "agent"
+	}
+
+	var notes []string
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		for _, path := range op.Paths() {
+			if gitCommitted(path) && !seen[path] {
+				seen[path] = true
+				notes = append(notes, filepath.ToSlash(path))
+			}
+		}
+	}
+	summary := strings.Join(notes, ", ")
+	if len(notes) > 3 {
+		summary = fmt.Sprintf("%s and %d more", strings.Join(notes[:3], ", "), len(notes)-3)
+	}
+
+	if len(ops) == 1 {
+		return fmt.Sprintf("%s: %s %s", source, ops[0].Action, summary)
+	}
+	return fmt.Sprintf("%s: %d changes to %s", source, len(ops), summary)
+}
+
+// gitBody lists each operation's changes and ends with the trailers that
+// mark the commit as made by an agent
+func gitBody(ops []*Operation) string {
+	var sb strings.Builder
+	var ids, sources []string
+	for _, op := range ops {
+		sb.WriteString(op.Action)
+		if op.ID != "" {
+			fmt.Fprintf(&sb, " (%s)", op.ID)
+			ids = append(ids, op.ID)
+		}
+		sb.WriteString(":\n")
+		for _, change := range op.Changes {
+			if gitCommitted(change.Path) {
+				fmt.Fprintf(&sb, "  %s %s\n", change.Kind(), filepath.ToSlash(change.Path))
+			}
+		}
+		if op.Source != "" && !slices.Contains(sources, op.Source) {
+			sources = append(sources, op.Source)
+		}
+	}
+	if len(sources) == 0 {
+		sources = []string{"agent"}
+	}
+
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "%s: %s\n", gitSourceTrailer, strings.Join(sources, ", "))
+	if len(ids) > 0 {
+		fmt.Fprintf(&sb, "Agent-Operations: %s\n", strings.Join(ids, ", "))
+	}
+	return sb.String()
+}
+
+// AgentCommit is a commit made by a GitCommitter
+type AgentCommit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Subject string    `json:"subject"`
+	// Files lists the vault files the commit changed with git's status
+	// letter: A, M or D
+	Files []CommitFile `json:"files"`
+}
+
+// CommitFile is a file changed by a commit
+type CommitFile struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+// AgentCommits lists the commits agents made to the vault since rev, newest first
+func (r *GitRepo) AgentCommits(since string) ([]AgentCommit, error) {
+	if _, err := r.run("rev-parse", "--verify", "--quiet", "--end-of-options", since+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown revision %q", since)
+	}
+	format := "%x1e%H%x1f%an <%ae>%x1f%aI%x1f%s%x1f%(trailers:key=" + gitSourceTrailer + ",valueonly,separator=%x2C%x20)"
+	args := []string{"log", "--no-renames", "--name-status", "--format=" + format, since + "..HEAD"}
+	if r.prefix != "" {
+		args = append(args, "--", r.prefix)
+	}
+	out, err := r.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []AgentCommit{}
+	for _, record := range strings.Split(out, "\x1e")[1:] {
+		header, files, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) < 5 || strings.TrimSpace(fields[4]) == "" {
+			continue
+		}
+		commit := AgentCommit{Hash: fields[0], Author: fields[1], Subject: fields[3], Source: strings.TrimSpace(fields[4]), Files: []CommitFile{}}
+		commit.Time, _ = time.Parse(time.RFC3339, fields[2])
+		for _, line := range strings.Split(files, "\n") {
+			status, path, ok := strings.Cut(line, "\t")
+			if !ok || !strings.HasPrefix(path, r.prefix) {
+				continue
+			}
+			commit.Files = append(commit.Files, CommitFile{Status: status, Path: strings.TrimPrefix(path, r.prefix)})
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// ChangedNotes returns the notes changed by any of the commits, sorted
+func ChangedNotes(commits []AgentCommit) []string {
+	seen := make(map[string]bool)
+	notes := []string{}
+	for _, commit := range commits {
+		for _, file := range commit.Files {
+			if !seen[file.Path] {
+				seen[file.Path] = true
+				notes = append(notes, file.Path)
+			}
+		}
+	}
+	sort.Strings(notes)
+	return notes
+}
+
+// GitCommitter commits the operations of writers it is set on, each on
+// its own or, when batching, together on Flush
+type GitCommitter struct {
+	repo   *GitRepo
+	author string
+	batch  bool
+
+	mu      sync.Mutex
+	pending []*Operation
+}
+
+// NewGitCommitter returns a committer for the vault's git repository,
+// batching operations until Flush in GitPerSession mode
+func NewGitCommitter(vaultPath string, config GitConfig) (*GitCommitter, error) {
+	if config.Author != "" && !gitAuthorRegex.MatchString(config.Author) {
+		return nil, fmt.Errorf("git author %q must look like \"Name <email>\"", config.Author)
+	}
+	repo, err := OpenGitRepo(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	return &GitCommitter{repo: repo, author: config.Author, batch: config.Mode == GitPerSession}, nil
+}
+
+// add commits an operation, or holds it for Flush when batching
+func (c *GitCommitter) add(op *Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.batch {
+		c.pending = append(c.pending, op)
+		return nil
+	}
+	_, err := c.repo.Commit([]*Operation{op}, c.author)
+	return err
+}
+
+// Flush commits the operations held while batching in a single commit
+func (c *GitCommitter) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) == 0 {
+		return nil
+	}
+	ops := c.pending
+	c.pending = nil
+	_, err := c.repo.Commit(ops, c.author)
+	return err
+}
+
+// SetGit commits every change the writer makes with c (nil disables it)
+func (w *Writer) SetGit(c *GitCommitter) {
+	w.git = c
+}
@@ -0,0 +1,456 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/fsutil"
+)
+
+// JournalFolder is the hidden vault folder operations are recorded in
+const JournalFolder = ".agent-journal"
+
+// JournalLimit is the number of operations kept; older ones are pruned
+const JournalLimit = 1000
+
+// ErrOperationNotFound is returned for an unknown operation id
+var ErrOperationNotFound = errors.New("operation not found")
+
+// Operation is one recorded mutation of the vault, with the content of every
+// file it changed before and after
+type Operation struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Source is the command or MCP tool call that made the change, e.g.
+	// "cli mv" or "mcp move_note"
+	Source string `json:"source,omitempty"`
+	// Action is the kind of change: create, append, edit, frontmatter,
+	// link, move, delete, restore, migrate or undo
+	Action  string       `json:"action"`
+	Changes []FileChange `json:"changes"`
+	// UndoOf is the operation an undo reverted
+	UndoOf string `json:"undo_of,omitempty"`
+	// UndoneBy is the undo operation that reverted this one
+	UndoneBy string `json:"undone_by,omitempty"`
+
+	locks map[string]*fsutil.FileLock // notes locked while the operation runs
+}
+
+// FileChange is a file's content before and after an operation. A nil
+// Before means the operation created the file, a nil After that it removed it.
+type FileChange struct {
+	Path   string  `json:"path"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// Paths lists the files the operation changed
+func (op *Operation) Paths() []string {
+	paths := make([]string, len(op.Changes))
+	for i, c := range op.Changes {
+		paths[i] = c.Path
+	}
+	return paths
+}
+
+// Journal stores operations as JSON files in the vault's JournalFolder
+type Journal struct {
+	dir string
+}
+
+// NewJournal returns the journal of a vault
+func NewJournal(vaultPath string) *Journal {
+	return &Journal{dir: filepath.Join(vaultPath, JournalFolder)}
+}
+
+// mkdirIgnored creates one of the agent's hidden vault folders with a
+// .gitignore inside, so vaults kept in git leave its contents untracked
+func mkdirIgnored(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); err == nil {
+		return nil
+	}
+	return os.WriteFile(ignore, []byte("*\n"), 0644)
+}
+
+// List returns up to limit operations, newest first (all when limit is 0)
+func (j *Journal) List(limit int) ([]*Operation, error) {
+	files, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(files) > limit {
+		files = files[len(files)-limit:]
+	}
+	ops := make([]*Operation, 0, len(files))
+	for i := len(files) - 1; i >= 0; i-- {
+		op, err := j.read(files[i])
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// Get returns the operation with the given id or unique id prefix
+func (j *Journal) Get(id string) (*Operation, error) {
+	files, err := j.files()
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, file := range files {
+		if strings.HasPrefix(operationID(file), id) {
+			matches = append(matches, file)
+		}
+	}
+	switch {
+	case id == "" || len(matches) == 0:
+		return nil, fmt.Errorf("%w: %s", ErrOperationNotFound, id)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("operation id %s is ambiguous, give more of it", id)
+	}
+	return j.read(matches[0])
+}
+
+// record saves a new operation and prunes the oldest beyond JournalLimit
+func (j *Journal) record(op *Operation) error {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to create operation id: %w", err)
+	}
+	op.ID = hex.EncodeToString(id)
+	op.Time = time.Now()
+	if err := mkdirIgnored(j.dir); err != nil {
+		return fmt.Errorf("failed to create journal folder: %w", err)
+	}
+	if err := j.save(op); err != nil {
+		return err
+	}
+
+	files, err := j.files()
+	if err != nil {
+		return err
+	}
+	for len(files) > JournalLimit {
+		os.Remove(filepath.Join(j.dir, files[0]))
+		files = files[1:]
+	}
+	return nil
+}
+
+// save writes an operation to its file, named so files sort by time
+func (j *Journal) save(op *Operation) error {
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %w", err)
+	}
+	name := fmt.Sprintf("%020d-%s.json", op.Time.UnixNano(), op.ID)
+	if err := fsutil.WriteFile(filepath.Join(j.dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// files lists the journal's operation files, oldest first
+func (j *Journal) files() ([]string, error) {
+	entries, err := os.ReadDir(j.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (j *Journal) read(file string) (*Operation, error) {
+	data, err := os.ReadFile(filepath.Join(j.dir, file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("failed to parse journal entry %s: %w", file, err)
+	}
+	return &op, nil
+}
+
+// operationID returns the id part of an operation file name
+func operationID(file string) string {
+	_, id, _ := strings.Cut(strings.TrimSuffix(file, ".json"), "-")
+	return id
+}
+
+// SetJournal records every change the writer makes in j (nil disables it)
+func (w *Writer) SetJournal(j *Journal) {
+	w.journal = j
+}
+
+// WithSource returns a copy of the writer whose journal entries are tagged
+// with source, the command or tool call making the changes
+func (w *Writer) WithSource(source string) *Writer {
+	c := *w
+	c.source = source
+	return &c
+}
+
+// begin starts an operation. Files changed through the returned writer are
+// recorded and committed together, and the notes it locked released, when
+// done is called with the operation's error; calls made within an
+// operation join it.
+func (w *Writer) begin(action string) (*Writer, func(err *error)) {
+	if w.plan != nil && w.op == nil {
+		// Dry runs collect every call's changes in the plan instead
+		c := *w
+		c.op = w.plan
+		return &c, func(*error) {}
+	}
+	if (w.journal == nil && w.git == nil && w.locker == nil) || w.op != nil {
+		return w, func(*error) {}
+	}
+	c := *w
+	c.op = &Operation{Source: w.source, Action: action}
+	return &c, func(err *error) {
+		// Notes stay locked until the change is recorded and committed
+		defer c.op.unlockAll()
+
+		// Changes are recorded even when the operation failed part way
+		var changes []FileChange
+		for _, change := range c.op.Changes {
+			if !sameContent(change.Before, change.After) {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			return
+		}
+		c.op.Changes = changes
+		if w.journal != nil {
+			if recErr := w.journal.record(c.op); recErr != nil && *err == nil {
+				*err = fmt.Errorf("changes were saved but not recorded for undo: %w", recErr)
+			}
+		}
+		if w.git != nil {
+			if gitErr := w.git.add(c.op); gitErr != nil && *err == nil {
+				*err = fmt.Errorf("changes were saved but not committed: %w", gitErr)
+			}
+		}
+	}
+}
+
+// recordChange notes a file's content before and after a change in the
+// current operation, keeping the earliest before and latest after
+func (w *Writer) recordChange(fullPath string, before, after []byte, existed, exists bool) {
+	if w.op == nil {
+		return
+	}
+	relPath, err := filepath.Rel(w.vaultPath, fullPath)
+	if err != nil {
+		return
+	}
+	for i := range w.op.Changes {
+		if w.op.Changes[i].Path == relPath {
+			w.op.Changes[i].After = contentPtr(after, exists)
+			return
+		}
+	}
+	w.op.Changes = append(w.op.Changes, FileChange{Path: relPath, Before: contentPtr(before, existed), After: contentPtr(after, exists)})
+}
+
+// forgetChange drops a file from the current operation, for changes that
+// were reverted before it finished
+func (w *Writer) forgetChange(fullPath string) {
+	if w.op == nil {
+		return
+	}
+	relPath, err := filepath.Rel(w.vaultPath, fullPath)
+	if err != nil {
+		return
+	}
+	for i := range w.op.Changes {
+		if w.op.Changes[i].Path == relPath {
+			w.op.Changes = append(w.op.Changes[:i], w.op.Changes[i+1:]...)
+			return
+		}
+	}
+}
+
+// writeFile writes a note atomically and records the change
+func (w *Writer) writeFile(fullPath string, data []byte) error {
+	before, err := w.readFile(fullPath)
+	existed := err == nil
+	if w.plan == nil {
+		if err := fsutil.WriteFile(fullPath, data, 0644); err != nil {
+			return err
+		}
+	}
+	w.recordChange(fullPath, before, data, existed, true)
+	return nil
+}
+
+// removeFile deletes a note and records the change
+func (w *Writer) removeFile(fullPath string) error {
+	before, err := w.readFile(fullPath)
+	if w.plan == nil {
+		if err := os.Remove(fullPath); err != nil {
+			return err
+		}
+	}
+	w.recordChange(fullPath, before, nil, err == nil, false)
+	return nil
+}
+
+// mkdirAll creates a note's folder, except in dry runs
+func (w *Writer) mkdirAll(dir string) error {
+	if w.plan != nil {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// moveFile moves a note within or out of the vault, using move (os.Rename,
+// fsutil.MoveFile), and records the change
+func (w *Writer) moveFile(from, to string, move func(from, to string) error) error {
+	content, err := w.readFile(from)
+	if w.plan == nil {
+		if err := move(from, to); err != nil {
+			return err
+		}
+	}
+	w.recordChange(from, content, nil, err == nil, false)
+	// Moves to the system trash leave the vault
+	if rel, relErr := filepath.Rel(w.vaultPath, to); relErr == nil && !escapes(rel) {
+		w.recordChange(to, nil, content, false, true)
+	}
+	return nil
+}
+
+func contentPtr(content []byte, exists bool) *string {
+	if !exists {
+		return nil
+	}
+	s := string(content)
+	return &s
+}
+
+func sameContent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// Undo reverts a recorded operation, restoring every file it changed. It
+// refuses, changing nothing, if any of those files has changed since. The
+// undo is recorded too, so it can itself be undone.
+func (w *Writer) Undo(id string) (*Operation, error) {
+	if w.journal == nil {
+		return nil, fmt.Errorf("undo needs a journal")
+	}
+	target, err := w.journal.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if target.UndoneBy != "" {
+		return nil, fmt.Errorf("operation %s was already undone by %s", target.ID, target.UndoneBy)
+	}
+
+	w, done := w.begin("undo")
+	undo := w.op
+	if undo != nil {
+		undo.UndoOf = target.ID
+	}
+	if err = w.checkUndo(target); err == nil {
+		err = w.revert(target)
+	}
+	done(&err)
+	if err != nil {
+		return nil, err
+	}
+
+	// The undo's id is assigned when it is recorded; dry runs have none
+	if undo != nil && undo.ID != "" {
+		target.UndoneBy = undo.ID
+		if err := w.journal.save(target); err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+// checkUndo locks the files an operation changed and checks that none has
+// changed since
+func (w *Writer) checkUndo(op *Operation) error {
+	for _, change := range op.Changes {
+		if !strings.HasPrefix(change.Path, TrashFolder+string(filepath.Separator)) {
+			if err := w.policy.CheckWrite(change.Path); err != nil {
+				return err
+			}
+		}
+		fullPath, _, err := w.paths.Resolve(change.Path)
+		if err != nil {
+			return err
+		}
+		if err := w.lock(fullPath); err != nil {
+			return err
+		}
+		current, readErr := w.readFile(fullPath)
+		if !sameContent(contentPtr(current, readErr == nil), change.After) {
+			return divergedError(op, change, current, readErr == nil)
+		}
+	}
+	return nil
+}
+
+// revert restores the files an operation changed to their content before it
+func (w *Writer) revert(op *Operation) error {
+	for i := len(op.Changes) - 1; i >= 0; i-- {
+		change := op.Changes[i]
+		fullPath := filepath.Join(w.vaultPath, change.Path)
+		var err error
+		if change.Before == nil {
+			err = w.removeFile(fullPath)
+		} else if err = w.mkdirAll(filepath.Dir(fullPath)); err == nil {
+			err = w.writeFile(fullPath, []byte(*change.Before))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
+
+// divergedError explains why a change can't be undone
+func divergedError(op *Operation, change FileChange, current []byte, exists bool) error {
+	switch {
+	case change.After == nil:
+		return fmt.Errorf("cannot undo %s: %s has been recreated since", op.ID, change.Path)
+	case !exists:
+		return fmt.Errorf("cannot undo %s: %w", op.ID, &ConflictError{Path: change.Path, Expected: ContentVersion([]byte(*change.After))})
+	}
+	return fmt.Errorf("cannot undo %s: %w", op.ID, &ConflictError{
+		Path:     change.Path,
+		Expected: ContentVersion([]byte(*change.After)),
+		Current:  ContentVersion(current),
+	})
+}
@@ -0,0 +1,166 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// journaledWriter returns a writer recording its changes in the vault's journal
+func journaledWriter(vaultPath string) (*Writer, *Journal) {
+	journal := NewJournal(vaultPath)
+	writer := NewWriter(vaultPath)
+	writer.SetJournal(journal)
+	return writer, journal
+}
+
+func TestUndoRestoresBefore(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"Plan.md": "# Plan\n"})
+	writer, journal := journaledWriter(vaultPath)
+
+	if _, err := writer.AppendToNote("Plan", "- ship it", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.CreateNote("Idea.md", "idea", nil); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := journal.List(0)
+	if err != nil || len(ops) != 2 {
+		t.Fatalf("got %d operations, %v, want 2", len(ops), err)
+	}
+	create, appendOp := ops[0], ops[1]
+	if c := appendOp.Changes[0]; c.Path != "Plan.md" || *c.Before != "# Plan\n" || !strings.Contains(*c.After, "- ship it") {
+		t.Errorf("append recorded %s: %v -> %v", c.Path, c.Before, c.After)
+	}
+	if c := create.Changes[0]; c.Before != nil || c.After == nil {
+		t.Errorf("create recorded before %v, after %v, want nil and the content", c.Before, c.After)
+	}
+
+	for _, op := range []*Operation{create, appendOp} {
+		if _, err := writer.Undo(op.ID); err != nil {
+			t.Fatalf("undo %s: %v", op.Action, err)
+		}
+	}
+	if got := readNote(t, vaultPath, "Plan.md"); got != "# Plan\n" {
+		t.Errorf("Plan.md is %q after undo", got)
+	}
+	if _, err := os.Stat(filepath.Join(vaultPath, "Idea.md")); !os.IsNotExist(err) {
+		t.Errorf("created note still exists after undo: %v", err)
+	}
+
+	undone, err := journal.Get(appendOp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	undo, err := journal.Get(undone.UndoneBy)
+	if err != nil || undo.UndoOf != appendOp.ID || undo.Action != "undo" {
+		t.Fatalf("undo entry %+v, %v does not point back to %s", undo, err, appendOp.ID)
+	}
+	if _, err := writer.Undo(appendOp.ID); err == nil || !strings.Contains(err.Error(), "already undone") {
+		t.Errorf("got %v undoing twice, want already undone", err)
+	}
+}
+
+func TestUndoRefusesChangedNote(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"Plan.md": "# Plan\n", "Other.md": "other\n"})
+	writer, journal := journaledWriter(vaultPath)
+	if _, err := writer.AppendToNote("Plan", "- ship it", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.AppendToNote("Other", "- more", ""); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := journal.List(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := "# Plan\n- edited by hand\n"
+	if err := os.WriteFile(filepath.Join(vaultPath, "Plan.md"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var conflict *ConflictError
+	if _, err := writer.Undo(ops[1].ID); !errors.As(err, &conflict) || conflict.Path != "Plan.md" {
+		t.Fatalf("got %v, want a conflict on Plan.md", err)
+	}
+	if got := readNote(t, vaultPath, "Plan.md"); got != edited {
+		t.Errorf("refused undo changed Plan.md to %q", got)
+	}
+
+	os.Remove(filepath.Join(vaultPath, "Other.md"))
+	if _, err := writer.Undo(ops[0].ID); !errors.As(err, &conflict) {
+		t.Errorf("got %v undoing a change to a deleted note, want a conflict", err)
+	}
+	if after, err := journal.List(0); err != nil || len(after) != 2 {
+		t.Errorf("refused undos were recorded: %d operations, %v", len(after), err)
+	}
+}
+
+func TestUndoOfUndo(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"Plan.md": "# Plan\n"})
+	writer, journal := journaledWriter(vaultPath)
+	if _, err := writer.AppendToNote("Plan", "- ship it", ""); err != nil {
+		t.Fatal(err)
+	}
+	appended := readNote(t, vaultPath, "Plan.md")
+	ops, err := journal.List(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Undo(ops[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	ops, err = journal.List(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Undo(ops[0].ID); err != nil {
+		t.Fatalf("undo of an undo: %v", err)
+	}
+	if got := readNote(t, vaultPath, "Plan.md"); got != appended {
+		t.Errorf("Plan.md is %q, want the append redone: %q", got, appended)
+	}
+}
+
+func TestJournalList(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"Plan.md": ""})
+	writer, journal := journaledWriter(vaultPath)
+	for _, text := range []string{"one", "two", "three"} {
+		if _, err := writer.AppendToNote("Plan", text, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{0, []string{"three", "two", "one"}},
+		{2, []string{"three", "two"}},
+		{5, []string{"three", "two", "one"}},
+	}
+	for _, tt := range tests {
+		ops, err := journal.List(tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) != len(tt.want) {
+			t.Fatalf("List(%d) returned %d operations, want %d", tt.limit, len(ops), len(tt.want))
+		}
+		for i, op := range ops {
+			if after := *op.Changes[0].After; !strings.HasSuffix(strings.TrimSpace(after), tt.want[i]) {
+				t.Errorf("List(%d)[%d] appended %q, want %q last", tt.limit, i, after, tt.want[i])
+			}
+		}
+	}
+
+	ops, _ := journal.List(0)
+	if got, err := journal.Get(ops[1].ID[:6]); err != nil || got.ID != ops[1].ID {
+		t.Errorf("Get by prefix returned %v, %v", got, err)
+	}
+	if _, err := journal.Get("ffffffffff"); !errors.Is(err, ErrOperationNotFound) {
+		t.Errorf("got %v for an unknown id, want ErrOperationNotFound", err)
+	}
+}
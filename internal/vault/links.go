@@ -0,0 +1,172 @@
+package vault
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// wikilinkTargetRegex matches [[wikilinks]] and ![[embeds]], capturing
+	// the target, the #heading or #^block subpath and the |display text
+	wikilinkTargetRegex = regexp.MustCompile(`(!?)\[\[([^\[\]|#]*)(#[^\[\]|]*)?(\|[^\[\]]*)?\]\]`)
+	// markdownLinkRegex matches [text](target) and ![alt](target) links
+	markdownLinkRegex = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\((<[^<>]+>|[^()\s]+)((?:\s+"[^"]*")?)\)`)
+)
+
+// Backlinks lists the notes with a wikilink, embed or markdown link that
+// resolves to notePath, not counting the note itself
+func (n *NoteResolver) Backlinks(notePath string) ([]string, error) {
+	notes, err := n.Notes()
+	if err != nil {
+		return nil, err
+	}
+
+	backlinks := []string{}
+	for _, note := range notes {
+		if note == notePath {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(n.vaultPath, note))
+		if err != nil {
+			continue
+		}
+		if linksTo(n, string(content), note, notePath, "") {
+			backlinks = append(backlinks, note)
+		}
+	}
+	return backlinks, nil
+}
+
+// linksTo reports whether content, the note at notePath, has a link that
+// resolves to target. For a target that doesn't exist yet, pass "" and the
+// link text name instead.
+func linksTo(resolver *NoteResolver, content, notePath, target, name string) bool {
+	for _, link := range ParseDocument(content).Links {
+		if target == "" {
+			if !link.Markdown && link.Target != "" && noteKey(link.Target) == noteKey(name) {
+				return true
+			}
+			continue
+		}
+		if resolved, ok := resolver.ResolveLink(link, notePath); ok && resolved == target {
+			return true
+		}
+	}
+	return false
+}
+
+// shortestLinkPath returns the shortest path suffix that identifies a note
+// among notes, without .md, like Obsidian's default link format
+func shortestLinkPath(notePath string, notes []string) string {
+	parts := strings.Split(filepath.ToSlash(notePath), "/")
+	for n := 1; n < len(parts); n++ {
+		suffix := strings.Join(parts[len(parts)-n:], "/")
+		key := noteKey(suffix)
+		matches := 0
+		for _, note := range notes {
+			if k := noteKey(note); k == key || strings.HasSuffix(k, "/"+key) {
+				matches++
+			}
+		}
+		if matches == 1 {
+			return strings.TrimSuffix(suffix, ".md")
+		}
+	}
+	return strings.TrimSuffix(filepath.ToSlash(notePath), ".md")
+}
+
+// rewriteLinks passes the submatches of every wikilink and markdown link
+// ParseDocument finds in content to wiki and markdown, replacing each link
+// with the result unless it is "". Links in code are never passed.
+func rewriteLinks(content string, wiki, markdown func(groups []string) string) string {
+	links := ParseDocument(content).Links
+	sort.Slice(links, func(i, j int) bool { return links[i].Start < links[j].Start })
+
+	var sb strings.Builder
+	last := 0
+	for _, link := range links {
+		raw := content[link.Start:link.End]
+		var replacement string
+		if link.Markdown {
+			replacement = markdown(markdownLinkRegex.FindStringSubmatch(raw))
+		} else {
+			replacement = wiki(wikilinkTargetRegex.FindStringSubmatch(raw))
+		}
+		if replacement == "" {
+			continue
+		}
+		sb.WriteString(content[last:link.Start])
+		sb.WriteString(replacement)
+		last = link.End
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
+
+// resolveLink finds the note a wikilink target written in notePath refers
+// to. Like Obsidian, an ambiguous link resolves to the shallowest candidate.
+func resolveLink(resolver *NoteResolver, target, notePath string) (string, bool) {
+	resolved, err := resolver.ResolveFrom(target, notePath)
+	if err == nil {
+		return resolved, true
+	}
+	var ambiguous *AmbiguousNoteError
+	if errors.As(err, &ambiguous) {
+		return ambiguous.Candidates[0], true
+	}
+	return "", false
+}
+
+// markdownTarget is the parsed target of a markdown link to a note
+type markdownTarget struct {
+	path    string // decoded note path, as written
+	subpath string // #heading or #^block, if any
+	angled  bool   // written as <path>
+	hasExt  bool   // path ends in .md
+}
+
+// parseMarkdownTarget parses the target of a markdown link, rejecting
+// URLs, same-note anchors and links to attachments
+func parseMarkdownTarget(target string) (markdownTarget, bool) {
+	parsed := markdownTarget{angled: strings.HasPrefix(target, "<")}
+	raw := strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	if strings.Contains(raw, "://") || strings.HasPrefix(raw, "mailto:") || strings.HasPrefix(raw, "#") {
+		return parsed, false
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return parsed, false
+	}
+	parsed.path = decoded
+	if i := strings.Index(decoded, "#"); i >= 0 {
+		parsed.path, parsed.subpath = decoded[:i], decoded[i:]
+	}
+	parsed.hasExt = strings.HasSuffix(strings.ToLower(parsed.path), ".md")
+	if path.Ext(parsed.path) != "" && !parsed.hasExt {
+		return parsed, false
+	}
+	return parsed, true
+}
+
+// resolveMarkdownLink finds the note a markdown link path written in
+// notePath refers to: relative to the note, or else to the vault root.
+// relative reports the former.
+func resolveMarkdownLink(resolver *NoteResolver, linkPath, notePath string) (resolved string, relative bool) {
+	if err := resolver.load(); err != nil {
+		return "", false
+	}
+	fromNote := path.Join(path.Dir(filepath.ToSlash(notePath)), linkPath)
+	fromRoot := strings.TrimPrefix(linkPath, "/")
+	for _, candidate := range []string{fromNote, fromRoot} {
+		if matches := resolver.byPath[noteKey(candidate)]; len(matches) > 0 {
+			return matches[0], candidate == fromNote && !strings.HasPrefix(linkPath, "/")
+		}
+	}
+	return "", false
+}
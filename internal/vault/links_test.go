@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	upper := func(groups []string) string {
+		return "<" + strings.ToUpper(groups[0]) + ">"
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"wikilinks and embeds", "a [[x]] b ![[y#h|z]]\n", "a <[[X]]> b <![[Y#H|Z]]>\n"},
+		{"markdown links", "[a](b.md) ![c](d.md \"t\")\n", "<[A](B.MD)> <![C](D.MD \"T\")>\n"},
+		{"inline code", "`[[x]]` [[x]] ``a `[[x]]` b``\n", "`[[x]]` <[[X]]> ``a `[[x]]` b``\n"},
+		{"fence", "```\n[[x]]\n```\n[[x]]\n", "```\n[[x]]\n```\n<[[X]]>\n"},
+		{"longer fence", "````\n```\n[[x]]\n```\n[[x]]\n````\n", "````\n```\n[[x]]\n```\n[[x]]\n````\n"},
+		{"tilde fence", "~~~\n[[x]]\n```\n~~~\n[[x]]\n", "~~~\n[[x]]\n```\n~~~\n<[[X]]>\n"},
+		{"frontmatter", "---\nup: \"[[x]]\"\n---\n", "---\nup: \"<[[X]]>\"\n---\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLinks(tt.content, upper, upper); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("empty replacement keeps the link", func(t *testing.T) {
+		content := "[[x]] [a](b.md)\n"
+		keep := func([]string) string { return "" }
+		if got := rewriteLinks(content, keep, keep); got != content {
+			t.Errorf("got %q, want %q", got, content)
+		}
+	})
+}
+
+func TestBacklinks(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Projects/Plan.md": "[[Plan]] links to itself\n",
+		"Wiki.md":          "See [[Plan#Goals|the plan]].\n",
+		"Embed.md":         "![[Projects/Plan]]\n",
+		"Markdown.md":      "[plan](Projects/Plan.md)\n",
+		"Alias.md":         "[[Roadmap]]\n",
+		"Code.md":          "`[[Plan]]`\n\n```\n[[Plan]]\n```\n",
+		"Other.md":         "[[Plans]]\n",
+		"Plans.md":         "---\naliases: [Roadmap]\n---\n",
+	})
+	got, err := NewNoteResolver(vaultPath).Backlinks("Projects/Plan.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Embed.md", "Markdown.md", "Wiki.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
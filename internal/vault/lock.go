@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/fsutil"
+)
+
+// LockFolder is the hidden vault folder holding the note lock files
+const LockFolder = ".agent-locks"
+
+// DefaultLockTimeout is how long to wait for a note another process is using
+const DefaultLockTimeout = 5 * time.Second
+
+// lockRetry is how often a busy note's lock is tried again
+const lockRetry = 25 * time.Millisecond
+
+// NoteBusyError reports a note locked by another process or operation for
+// longer than the lock timeout
+type NoteBusyError struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *NoteBusyError) Error() string {
+	return fmt.Sprintf("note busy: %s is being changed by another process (waited %s), try again", e.Path, e.Timeout)
+}
+
+// Details returns the error as structured data for JSON and MCP clients
+func (e *NoteBusyError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":    "note_busy",
+		"path":    e.Path,
+		"timeout": e.Timeout.String(),
+	}
+}
+
+// NoteLocker hands out advisory per-note locks shared by every process
+// working on a vault: writers lock the notes they change, readers wait
+// for those changes to finish
+type NoteLocker struct {
+	dir     string
+	timeout time.Duration
+}
+
+// NewNoteLocker returns the locker of a vault
+func NewNoteLocker(vaultPath string) *NoteLocker {
+	return &NoteLocker{dir: filepath.Join(vaultPath, LockFolder), timeout: DefaultLockTimeout}
+}
+
+// SetTimeout sets how long to wait for a busy note
+func (l *NoteLocker) SetTimeout(d time.Duration) {
+	l.timeout = d
+}
+
+// Lock locks a note, given by its vault-relative path, waiting up to the
+// timeout while another holder has it. Shared locks are for reading.
+func (l *NoteLocker) Lock(relPath string, shared bool) (*fsutil.FileLock, error) {
+	if err := mkdirIgnored(l.dir); err != nil {
+		return nil, fmt.Errorf("failed to create lock folder: %w", err)
+	}
+	// Paths differing only in case are the same note on some file systems
+	sum := sha256.Sum256([]byte(strings.ToLower(filepath.ToSlash(relPath))))
+	lockPath := filepath.Join(l.dir, hex.EncodeToString(sum[:8])+".lock")
+
+	deadline := time.Now().Add(l.timeout)
+	for {
+		lock, err := fsutil.TryLock(lockPath, shared)
+		if !errors.Is(err, fsutil.ErrLocked) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to lock %s: %w", relPath, err)
+			}
+			return lock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, &NoteBusyError{Path: relPath, Timeout: l.timeout}
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// SetLocker makes the writer lock each note it changes until the operation
+// changing it is done (nil disables locking)
+func (w *Writer) SetLocker(l *NoteLocker) {
+	w.locker = l
+}
+
+// lock locks a note for the rest of the current operation. Notes the
+// operation already holds are not locked again.
+func (w *Writer) lock(fullPath string) error {
+	if w.locker == nil || w.op == nil || w.plan != nil {
+		return nil
+	}
+	relPath, err := filepath.Rel(w.vaultPath, fullPath)
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(filepath.ToSlash(relPath))
+	if _, held := w.op.locks[key]; held {
+		return nil
+	}
+	lock, err := w.locker.Lock(relPath, false)
+	if err != nil {
+		return err
+	}
+	if w.op.locks == nil {
+		w.op.locks = make(map[string]*fsutil.FileLock)
+	}
+	w.op.locks[key] = lock
+	return nil
+}
+
+// unlockAll releases the locks an operation holds
+func (op *Operation) unlockAll() {
+	for key, lock := range op.locks {
+		lock.Unlock()
+		delete(op.locks, key)
+	}
+}
+
+// SetLocker makes the reader wait for notes being changed to be done
+// before reading them (nil disables locking)
+func (r *Reader) SetLocker(l *NoteLocker) {
+	r.locker = l
+}
+
+// readLocked reads a note under a shared lock
+func (r *Reader) readLocked(fullPath, relPath string) ([]byte, error) {
+	if r.locker != nil {
+		lock, err := r.locker.Lock(relPath, true)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Unlock()
+	}
+	return os.ReadFile(fullPath)
+}
@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNoteLockerTimeout(t *testing.T) {
+	vaultPath := t.TempDir()
+	first := NewNoteLocker(vaultPath)
+	lock, err := first.Lock("Projects/Plan.md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := NewNoteLocker(vaultPath)
+	second.SetTimeout(100 * time.Millisecond)
+	start := time.Now()
+	_, err = second.Lock("projects/plan.md", true)
+	var busy *NoteBusyError
+	if !errors.As(err, &busy) || busy.Timeout != 100*time.Millisecond {
+		t.Fatalf("got %v while the note is locked, want a note busy error", err)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond || waited > 2*time.Second {
+		t.Errorf("waited %s, want about the 100ms timeout", waited)
+	}
+
+	other, err := second.Lock("Projects/Other.md", false)
+	if err != nil {
+		t.Fatalf("locking another note: %v", err)
+	}
+	other.Unlock()
+
+	lock.Unlock()
+	again, err := second.Lock("Projects/Plan.md", false)
+	if err != nil {
+		t.Fatalf("locking after unlock: %v", err)
+	}
+	again.Unlock()
+}
+
+func TestNoteLockerShared(t *testing.T) {
+	vaultPath := t.TempDir()
+	locker := NewNoteLocker(vaultPath)
+	locker.SetTimeout(50 * time.Millisecond)
+	a, err := locker.Lock("Plan.md", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Unlock()
+	b, err := locker.Lock("Plan.md", true)
+	if err != nil {
+		t.Fatalf("second shared lock: %v", err)
+	}
+	defer b.Unlock()
+	var busy *NoteBusyError
+	if _, err := locker.Lock("Plan.md", false); !errors.As(err, &busy) {
+		t.Errorf("got %v for an exclusive lock on a read-locked note, want busy", err)
+	}
+}
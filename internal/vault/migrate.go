@@ -0,0 +1,417 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/diff"
+	"github.com/chadmowery/obsidian-agent-tools/internal/fsutil"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	inlineTagRegex  = regexp.MustCompile(`(^|[ \t])#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	inlineCodeRegex = regexp.MustCompile("`[^`]*`")
+)
+
+// Migration is a declarative change to the properties of many notes,
+// usually loaded from a YAML spec:
+//
+//	where:
+//	  folder: Projects
+//	steps:
+//	  - rename: state
+//	    to: status
+//	  - split: tags
+//	  - inline_tags: true
+//	  - default: status
+//	    value: draft
+//	    where: {tag: project}
+type Migration struct {
+	Where MigrationFilter `yaml:"where"`
+	Steps []MigrationStep `yaml:"steps"`
+}
+
+// MigrationFilter selects notes by folder, subfolders included, and by tag,
+// nested tags included. Empty fields match every note.
+type MigrationFilter struct {
+	Folder string `yaml:"folder"`
+	Tag    string `yaml:"tag"`
+}
+
+// MigrationStep is one change in a migration. Exactly one of Rename, Split,
+// InlineTags and Default is set.
+type MigrationStep struct {
+	// Rename renames a property to To, keeping its value as written
+	Rename string `yaml:"rename"`
+	To     string `yaml:"to"`
+
+	// Split turns a text property such as tags: "a, b" into a list,
+	// splitting on Separator (default ",")
+	Split     string `yaml:"split"`
+	Separator string `yaml:"separator"`
+
+	// InlineTags moves #tags from the body into the tags property, or
+	// copies them when Keep is set
+	InlineTags bool `yaml:"inline_tags"`
+	Keep       bool `yaml:"keep"`
+
+	// Default sets a property to Value on notes that don't have it
+	Default string      `yaml:"default"`
+	Value   interface{} `yaml:"value"`
+
+	// Where narrows the notes this step applies to
+	Where *MigrationFilter `yaml:"where"`
+}
+
+// String describes the step for summaries
+func (s MigrationStep) String() string {
+	switch {
+	case s.Rename != "":
+		return fmt.Sprintf("rename %s to %s", s.Rename, s.To)
+	case s.Split != "":
+		return fmt.Sprintf("split %s", s.Split)
+	case s.InlineTags && s.Keep:
+		return "copy inline tags"
+	case s.InlineTags:
+		return "move inline tags"
+	}
+	return fmt.Sprintf("default %s", s.Default)
+}
+
+// LoadMigration reads a migration spec from a YAML file
+func LoadMigration(file string) (*Migration, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration: %w", err)
+	}
+	return ParseMigration(data)
+}
+
+// ParseMigration parses and checks a YAML migration spec
+func ParseMigration(data []byte) (*Migration, error) {
+	var m Migration
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse migration: %w", err)
+	}
+	if len(m.Steps) == 0 {
+		return nil, fmt.Errorf("migration has no steps")
+	}
+	for i, step := range m.Steps {
+		actions := 0
+		for _, set := range []bool{step.Rename != "", step.Split != "", step.InlineTags, step.Default != ""} {
+			if set {
+				actions++
+			}
+		}
+		switch {
+		case actions != 1:
+			return nil, fmt.Errorf("migration step %d must have exactly one of rename, split, inline_tags and default", i+1)
+		case step.Rename != "" && step.To == "":
+			return nil, fmt.Errorf("migration step %d renames %s but has no \"to\"", i+1, step.Rename)
+		case step.Default != "" && step.Value == nil:
+			return nil, fmt.Errorf("migration step %d sets a default for %s but has no \"value\"", i+1, step.Default)
+		}
+	}
+	return &m, nil
+}
+
+// MigrationChange is a note a migration changes
+type MigrationChange struct {
+	Path string `json:"path"`
+	// Steps describes the steps that changed the note
+	Steps []string `json:"steps"`
+	// Diff is a unified diff of the change
+	Diff string `json:"diff"`
+
+	before, after string
+}
+
+// MigrationResult describes a planned or applied migration
+type MigrationResult struct {
+	// Scanned is the number of notes read, Matched those the migration's
+	// filter selected
+	Scanned int                `json:"scanned"`
+	Matched int                `json:"matched"`
+	Changes []*MigrationChange `json:"changes"`
+	// Skipped lists notes left alone and why, e.g. invalid frontmatter
+	Skipped []string `json:"skipped"`
+	Applied bool     `json:"applied"`
+}
+
+// PlanMigration runs a migration in memory over every note the policy lets
+// us read and returns the changes it would make, without writing anything
+func (w *Writer) PlanMigration(m *Migration) (*MigrationResult, error) {
+	resolver := NewNoteResolver(w.vaultPath)
+	resolver.SetPolicy(w.policy)
+	notes, err := resolver.Notes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{Changes: []*MigrationChange{}, Skipped: []string{}}
+	for _, note := range notes {
+		data, err := os.ReadFile(filepath.Join(w.vaultPath, note))
+		if err != nil {
+			continue
+		}
+		result.Scanned++
+		before := string(data)
+		if !m.Where.matches(note, before) {
+			continue
+		}
+		result.Matched++
+
+		content := before
+		var applied []string
+		for _, step := range m.Steps {
+			if step.Where != nil && !step.Where.matches(note, content) {
+				continue
+			}
+			updated, err := step.apply(content)
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %s: %v", note, step, err))
+				applied = nil
+				content = before
+				break
+			}
+			if updated != content {
+				applied = append(applied, step.String())
+				content = updated
+			}
+		}
+		if content == before {
+			continue
+		}
+		if err := w.policy.CheckWrite(note); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", note, err))
+			continue
+		}
+		result.Changes = append(result.Changes, &MigrationChange{
+			Path:   note,
+			Steps:  applied,
+			Diff:   diff.Unified("a/"+filepath.ToSlash(note), "b/"+filepath.ToSlash(note), before, content),
+			before: before,
+			after:  content,
+		})
+	}
+	return result, nil
+}
+
+// ApplyMigration writes the changes of a planned migration. Nothing is
+// written if any note changed since it was planned, and notes already
+// written are restored if a write fails, so either every change is applied
+// or none is.
+func (w *Writer) ApplyMigration(result *MigrationResult) (err error) {
+	w, done := w.begin("migrate")
+	defer done(&err)
+
+	for _, change := range result.Changes {
+		if err := w.lock(filepath.Join(w.vaultPath, change.Path)); err != nil {
+			return err
+		}
+		current, err := os.ReadFile(filepath.Join(w.vaultPath, change.Path))
+		if err != nil {
+			return fmt.Errorf("failed to read note: %w", err)
+		}
+		if string(current) != change.before {
+			return &ConflictError{Path: change.Path, Expected: ContentVersion([]byte(change.before)), Current: ContentVersion(current)}
+		}
+	}
+
+	for i, change := range result.Changes {
+		if err := w.writeFile(filepath.Join(w.vaultPath, change.Path), []byte(change.after)); err != nil {
+			if failed := w.rollbackMigration(result.Changes[:i]); len(failed) > 0 {
+				return fmt.Errorf("failed to write %s, and could not restore %s: %w", change.Path, strings.Join(failed, ", "), err)
+			}
+			return fmt.Errorf("failed to write %s, migration rolled back: %w", change.Path, err)
+		}
+	}
+	result.Applied = true
+	return nil
+}
+
+// rollbackMigration restores notes already written by a failed migration
+// and returns those it couldn't. Restored notes are dropped from the
+// operation rather than journaled as a second change; those left migrated
+// stay recorded so they can still be undone.
+func (w *Writer) rollbackMigration(written []*MigrationChange) []string {
+	failed := []string{}
+	for _, change := range written {
+		fullPath := filepath.Join(w.vaultPath, change.Path)
+		if err := fsutil.WriteFile(fullPath, []byte(change.before), 0644); err != nil {
+			failed = append(failed, change.Path)
+			continue
+		}
+		w.forgetChange(fullPath)
+	}
+	return failed
+}
+
+// apply runs the step on a note's content
+func (s MigrationStep) apply(content string) (string, error) {
+	fm, err := EditFrontmatter(content)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case s.Rename != "":
+		if _, err := fm.Rename(s.Rename, s.To); err != nil {
+			return "", err
+		}
+
+	case s.Split != "":
+		value, ok := fm.Get(s.Split)
+		text, isText := value.(string)
+		if !ok || !isText {
+			return content, nil
+		}
+		separator := s.Separator
+		if separator == "" {
+			separator = ","
+		}
+		items := []interface{}{}
+		for _, item := range strings.Split(text, separator) {
+			item = strings.TrimSpace(item)
+			if s.Split == "tags" {
+				item = strings.TrimPrefix(item, "#")
+			}
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		if err := fm.Set(s.Split, items); err != nil {
+			return "", err
+		}
+
+	case s.InlineTags:
+		tags := ParseDocument(content).InlineTags()
+		if len(tags) == 0 {
+			return content, nil
+		}
+		current, _ := fm.Get("tags")
+		list := propertyList(current)
+		for _, tag := range tags {
+			if indexOfValue("tags", list, tag) < 0 {
+				list = append(list, tag)
+			}
+		}
+		if err := fm.Set("tags", list); err != nil {
+			return "", err
+		}
+		content = fm.String()
+		if s.Keep {
+			return content, nil
+		}
+		return removeInlineTags(content), nil
+
+	default:
+		if value, ok := fm.Get(s.Default); ok && value != nil {
+			return content, nil
+		}
+		if err := fm.Set(s.Default, s.Value); err != nil {
+			return "", err
+		}
+	}
+	return fm.String(), nil
+}
+
+// matches reports whether a note is selected by the filter
+func (f MigrationFilter) matches(notePath, content string) bool {
+	if folder := strings.Trim(filepath.ToSlash(f.Folder), "/"); folder != "" {
+		if !strings.HasPrefix(strings.ToLower(filepath.ToSlash(notePath)), strings.ToLower(folder)+"/") {
+			return false
+		}
+	}
+	if f.Tag == "" {
+		return true
+	}
+	want := strings.ToLower(strings.TrimPrefix(f.Tag, "#"))
+	for _, tag := range ParseDocument(content).AllTags() {
+		tag = strings.ToLower(tag)
+		if tag == want || strings.HasPrefix(tag, want+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeInlineTags deletes the #tags from a note's body, dropping lines
+// that held nothing but tags
+func removeInlineTags(content string) string {
+	return eachProseLine(content, func(line string) (string, bool) {
+		matches := inlineTagMatches(line)
+		if len(matches) == 0 {
+			return line, true
+		}
+		var sb strings.Builder
+		last := 0
+		for _, m := range matches {
+			sb.WriteString(line[last:m[0]])
+			last = m[1]
+			if m[2] == m[3] {
+				// A tag starting the line takes the space after it along
+				for last < len(line) && (line[last] == ' ' || line[last] == '\t') {
+					last++
+				}
+			}
+		}
+		sb.WriteString(line[last:])
+		updated := strings.TrimRight(sb.String(), " \t")
+		// Drop lines and list items left empty
+		return updated, strings.Trim(updated, " \t-*+") != ""
+	})
+}
+
+// inlineTagMatches finds the tags on a line, skipping `inline code` and
+// the #headings of links
+func inlineTagMatches(line string) [][]int {
+	skip := inlineCodeRegex.FindAllStringIndex(line, -1)
+	if strings.Contains(line, "[") {
+		skip = append(skip, wikilinkTargetRegex.FindAllStringIndex(line, -1)...)
+		skip = append(skip, markdownLinkRegex.FindAllStringIndex(line, -1)...)
+	}
+	var matches [][]int
+	for _, m := range inlineTagRegex.FindAllStringSubmatchIndex(line, -1) {
+		skipped := false
+		for _, c := range skip {
+			if m[4] >= c[0] && m[4] < c[1] {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// eachProseLine rewrites the lines of a note's body outside code blocks,
+// removing those rewrite doesn't keep
+func eachProseLine(content string, rewrite func(line string) (string, bool)) string {
+	doc := ParseDocument(content)
+	var sb strings.Builder
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		start := offset
+		offset += len(line)
+		text := strings.TrimRight(line, "\r\n")
+		ending := line[len(text):]
+		if start >= doc.BodyStart && !doc.inCode(start) {
+			var keep bool
+			if text, keep = rewrite(text); !keep {
+				continue
+			}
+		}
+		sb.WriteString(text + ending)
+	}
+	return sb.String()
+}
@@ -0,0 +1,212 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMigration(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		err  string
+	}{
+		{"valid", "where: {folder: Projects}\nsteps:\n  - rename: state\n    to: status\n  - split: tags\n", ""},
+		{"no steps", "steps: []\n", "has no steps"},
+		{"two actions", "steps:\n  - rename: a\n    to: b\n    split: c\n", "exactly one"},
+		{"rename without to", "steps:\n  - rename: a\n", "has no \"to\""},
+		{"default without value", "steps:\n  - default: status\n", "has no \"value\""},
+		{"unknown field", "steps:\n  - renam: a\n", "failed to parse migration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMigration([]byte(tt.spec))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMigrationStepApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    MigrationStep
+		content string
+		want    string
+	}{
+		{
+			name:    "rename",
+			step:    MigrationStep{Rename: "state", To: "status"},
+			content: "---\nstate: \"open\" # now\n---\n",
+			want:    "---\nstatus: \"open\" # now\n---\n",
+		},
+		{
+			name:    "split",
+			step:    MigrationStep{Split: "tags"},
+			content: "---\ntags: \"#a, b,, c \"\n---\n",
+			want:    "---\ntags:\n  - a\n  - b\n  - c\n---\n",
+		},
+		{
+			name:    "split a list does nothing",
+			step:    MigrationStep{Split: "tags"},
+			content: "---\ntags: [a, b]\n---\n",
+			want:    "---\ntags: [a, b]\n---\n",
+		},
+		{
+			name:    "default",
+			step:    MigrationStep{Default: "status", Value: "draft"},
+			content: "---\ntitle: Plan\n---\n",
+			want:    "---\ntitle: Plan\nstatus: draft\n---\n",
+		},
+		{
+			name:    "default keeps a value",
+			step:    MigrationStep{Default: "status", Value: "draft"},
+			content: "---\nstatus: done\n---\n",
+			want:    "---\nstatus: done\n---\n",
+		},
+		{
+			name:    "move inline tags",
+			step:    MigrationStep{InlineTags: true},
+			content: "---\ntags: [a]\n---\n#b\nText #c and #a here\n- #d\n",
+			want:    "---\ntags: [a, b, c, d]\n---\nText and here\n",
+		},
+		{
+			name:    "copy inline tags",
+			step:    MigrationStep{InlineTags: true, Keep: true},
+			content: "Text #b\n",
+			want:    "---\ntags:\n  - b\n---\nText #b\n",
+		},
+		{
+			name:    "inline tags in code and links stay",
+			step:    MigrationStep{InlineTags: true},
+			content: "`#code` [[Note#heading]] #tag\n\n````\n```\n#fenced\n```\n````\n",
+			want:    "---\ntags:\n  - tag\n---\n`#code` [[Note#heading]]\n\n````\n```\n#fenced\n```\n````\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.step.apply(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanAndApplyMigration(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Projects/Plan.md":    "---\nstate: open\n---\n#project\n",
+		"Projects/Todo.md":    "---\nstatus: done\n---\n",
+		"Projects/Broken.md":  "---\nstate: [\n---\n",
+		"Areas/Health.md":     "---\nstate: open\n---\n",
+		"Projects/Unmoved.md": "No properties\n",
+	})
+	m, err := ParseMigration([]byte("where: {folder: Projects}\nsteps:\n  - rename: state\n    to: status\n  - default: status\n    value: draft\n    where: {tag: project}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWriter(vaultPath)
+	result, err := w.PlanMigration(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scanned != 5 || result.Matched != 4 {
+		t.Errorf("scanned %d and matched %d notes, want 5 and 4", result.Scanned, result.Matched)
+	}
+	var changed []string
+	for _, change := range result.Changes {
+		changed = append(changed, filepath.ToSlash(change.Path))
+	}
+	if want := []string{"Projects/Plan.md"}; !reflect.DeepEqual(changed, want) {
+		t.Fatalf("changes %v, want %v", changed, want)
+	}
+	if len(result.Skipped) != 1 || !strings.HasPrefix(result.Skipped[0], filepath.Join("Projects", "Broken.md")) {
+		t.Errorf("skipped %v, want Projects/Broken.md", result.Skipped)
+	}
+	if got := readNote(t, vaultPath, "Projects/Plan.md"); got != "---\nstate: open\n---\n#project\n" {
+		t.Errorf("planning changed the note: %q", got)
+	}
+
+	if err := w.ApplyMigration(result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Applied {
+		t.Error("result not marked applied")
+	}
+	if got, want := readNote(t, vaultPath, "Projects/Plan.md"), "---\nstatus: open\n---\n#project\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestApplyMigrationConflict(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"A.md": "---\nstate: open\n---\n",
+		"B.md": "---\nstate: open\n---\n",
+	})
+	m, err := ParseMigration([]byte("steps:\n  - rename: state\n    to: status\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWriter(vaultPath)
+	result, err := w.PlanMigration(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vaultPath, "B.md"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var conflict *ConflictError
+	if err := w.ApplyMigration(result); !errors.As(err, &conflict) {
+		t.Fatalf("got %v, want a conflict", err)
+	}
+	if got := readNote(t, vaultPath, "A.md"); got != "---\nstate: open\n---\n" {
+		t.Errorf("A.md was written despite the conflict: %q", got)
+	}
+}
+
+func TestRollbackMigration(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"A.md": "before\n"})
+	w := NewWriter(vaultPath)
+	w.SetJournal(NewJournal(vaultPath))
+	op, done := w.begin("migrate")
+
+	change := &MigrationChange{Path: "A.md", before: "before\n", after: "after\n"}
+	if err := op.writeFile(filepath.Join(vaultPath, "A.md"), []byte(change.after)); err != nil {
+		t.Fatal(err)
+	}
+	if failed := op.rollbackMigration([]*MigrationChange{change}); len(failed) > 0 {
+		t.Fatalf("failed to restore %v", failed)
+	}
+	if len(op.op.Changes) != 0 {
+		t.Errorf("restored notes left in the operation: %v", op.op.Paths())
+	}
+	var err error
+	done(&err)
+
+	if got := readNote(t, vaultPath, "A.md"); got != "before\n" {
+		t.Errorf("got %q, want the note restored", got)
+	}
+	ops, err := NewJournal(vaultPath).List(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("journal holds %d operations, want none", len(ops))
+	}
+}
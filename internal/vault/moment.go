@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// momentTokens lists the moment.js format tokens we understand, longest first
+// so that e.g. "YYYY" is matched before "YY"
+var momentTokens = []string{
+	"YYYY", "GGGG", "gggg", "MMMM", "dddd", "DDDD",
+	"MMM", "ddd", "DDD",
+	"YY", "GG", "gg", "MM", "Mo", "DD", "Do", "dd", "HH", "hh", "mm", "ss", "WW", "Wo", "ww", "wo", "Qo", "ZZ",
+	"M", "D", "d", "E", "e", "H", "h", "m", "s", "A", "a", "W", "w", "Q", "Z", "X", "x",
+}
+
+// momentLayouts maps moment.js tokens to their Go layout equivalents.
+// Tokens missing here have no Go equivalent and are computed by formatToken.
+var momentLayouts = map[string]string{
+	"YYYY": "2006",
+	"YY":   "06",
+	"MMMM": "January",
+	"MMM":  "Jan",
+	"MM":   "01",
+	"M":    "1",
+	"DDDD": "002",
+	"DD":   "02",
+	"D":    "2",
+	"dddd": "Monday",
+	"ddd":  "Mon",
+	"HH":   "15",
+	"hh":   "03",
+	"h":    "3",
+	"mm":   "04",
+	"m":    "4",
+	"ss":   "05",
+	"s":    "5",
+	"A":    "PM",
+	"a":    "pm",
+	"ZZ":   "-0700",
+	"Z":    "-07:00",
+}
+
+// momentToken is a format token or a run of literal text
+type momentToken struct {
+	text    string
+	literal bool
+}
+
+// tokenizeMoment splits a moment.js format string into tokens and literals.
+// Text in [brackets] is literal, as are characters that aren't tokens.
+func tokenizeMoment(format string) []momentToken {
+	var tokens []momentToken
+	appendLiteral := func(s string) {
+		if n := len(tokens); n > 0 && tokens[n-1].literal {
+			tokens[n-1].text += s
+			return
+		}
+		tokens = append(tokens, momentToken{text: s, literal: true})
+	}
+
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				appendLiteral(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, tok := range momentTokens {
+			if strings.HasPrefix(format[i:], tok) {
+				tokens = append(tokens, momentToken{text: tok})
+				i += len(tok)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			appendLiteral(format[i : i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// FormatMoment formats t with a moment.js format string, supporting every
+// token in momentTokens
+func FormatMoment(t time.Time, format string) string {
+	var b strings.Builder
+	for _, tok := range tokenizeMoment(format) {
+		if tok.literal {
+			b.WriteString(tok.text)
+			continue
+		}
+		b.WriteString(formatToken(t, tok.text))
+	}
+	return b.String()
+}
+
+// formatToken renders a single moment.js token
+func formatToken(t time.Time, tok string) string {
+	if layout, ok := momentLayouts[tok]; ok {
+		return t.Format(layout)
+	}
+
+	isoYear, isoWeek := t.ISOWeek()
+	localeYear, localeWeek := localeWeek(t)
+
+	switch tok {
+	case "Mo":
+		return ordinal(int(t.Month()))
+	case "Do":
+		return ordinal(t.Day())
+	case "DDD":
+		return strconv.Itoa(t.YearDay())
+	case "dd":
+		return t.Weekday().String()[:2]
+	case "d", "e":
+		return strconv.Itoa(int(t.Weekday()))
+	case "E":
+		return strconv.Itoa((int(t.Weekday())+6)%7 + 1)
+	case "H":
+		return strconv.Itoa(t.Hour())
+	case "Q":
+		return strconv.Itoa(quarter(t))
+	case "Qo":
+		return ordinal(quarter(t))
+	case "W":
+		return strconv.Itoa(isoWeek)
+	case "WW":
+		return fmt.Sprintf("%02d", isoWeek)
+	case "Wo":
+		return ordinal(isoWeek)
+	case "w":
+		return strconv.Itoa(localeWeek)
+	case "ww":
+		return fmt.Sprintf("%02d", localeWeek)
+	case "wo":
+		return ordinal(localeWeek)
+	case "GGGG":
+		return fmt.Sprintf("%04d", isoYear)
+	case "GG":
+		return fmt.Sprintf("%02d", isoYear%100)
+	case "gggg":
+		return fmt.Sprintf("%04d", localeYear)
+	case "gg":
+		return fmt.Sprintf("%02d", localeYear%100)
+	case "X":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "x":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return tok
+}
+
+// localeWeek returns the week-year and week number in moment's default "en"
+// locale: weeks start on Sunday and week 1 is the week containing January 1st
+func localeWeek(t time.Time) (year, week int) {
+	saturday := t.AddDate(0, 0, 6-int(t.Weekday()))
+	return saturday.Year(), (saturday.YearDay()-1)/7 + 1
+}
+
+// quarter returns the quarter of the year (1-4)
+func quarter(t time.Time) int {
+	return (int(t.Month())-1)/3 + 1
+}
+
+// ordinal formats n with its English ordinal suffix (1st, 2nd, 3rd, 4th)
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatMoment(t *testing.T) {
+	// Sunday, January 1st 2023 is in ISO week 52 of 2022 but locale week 1
+	sunday := time.Date(2023, 1, 1, 15, 4, 5, 0, time.UTC)
+	tuesday := time.Date(2024, 3, 12, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		t      time.Time
+		want   string
+	}{
+		{"YYYY-MM-DD", tuesday, "2024-03-12"},
+		{"YYYY/MM/DD dddd", tuesday, "2024/03/12 Tuesday"},
+		{"D MMM YY", tuesday, "12 Mar 24"},
+		{"MMMM Do", tuesday, "March 12th"},
+		{"Do", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "1st"},
+		{"Do", time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC), "22nd"},
+		{"Do", time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), "13th"},
+		{"DDD DDDD", tuesday, "72 072"},
+		{"dd d E", sunday, "Su 0 7"},
+		{"H:mm A", sunday, "15:04 PM"},
+		{"h:mm a", tuesday, "9:30 am"},
+		{"Q Qo", tuesday, "1 1st"},
+		{"GGGG-[W]WW", sunday, "2022-W52"},
+		{"gggg-[W]ww", sunday, "2023-W01"},
+		{"[Week] Wo", tuesday, "Week 11th"},
+		{"X", sunday, "1672585445"},
+		{"[YYYY] YYYY", tuesday, "YYYY 2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := FormatMoment(tt.t, tt.format); got != tt.want {
+				t.Errorf("FormatMoment(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MoveResult describes a moved note and the links rewritten to follow it
+type MoveResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Updated lists the notes whose links were rewritten, by their path after the move
+	Updated []string `json:"updated"`
+	// Links is the number of links rewritten
+	Links int `json:"links"`
+	// Skipped lists notes linking to the moved note that the policy doesn't
+	// let us write; their links are left pointing at the old path
+	Skipped []string `json:"skipped"`
+}
+
+// MoveNote renames or moves a note and rewrites every wikilink, embed,
+// heading or block link and markdown link that points to it, keeping
+// display text and aliases. Relative links inside the moved note are
+// updated for its new folder. A destination ending in "/" or naming an
+// existing folder keeps the note's file name.
+func (w *Writer) MoveNote(name, dest string) (_ *MoveResult, err error) {
+	w, done := w.begin("move")
+	defer done(&err)
+
+	fromFull, fromRel, err := w.resolveExisting(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.policy.CheckWrite(fromRel); err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(filepath.ToSlash(dest), "/") {
+		dest = path.Join(filepath.ToSlash(dest), filepath.Base(fromRel))
+	} else if destFull, _, err := w.paths.Resolve(dest); err == nil {
+		if info, err := os.Stat(destFull); err == nil && info.IsDir() {
+			dest = path.Join(filepath.ToSlash(dest), filepath.Base(fromRel))
+		}
+	}
+	toFull, toRel, err := w.paths.ResolveNote(dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.policy.CheckWrite(toRel); err != nil {
+		return nil, err
+	}
+	if toRel == fromRel {
+		return nil, fmt.Errorf("%s is already at %s", name, toRel)
+	}
+	if err := w.lock(fromFull); err != nil {
+		return nil, err
+	}
+	if err := w.lock(toFull); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(toFull); err == nil {
+		// A case-only rename finds the note itself on case-insensitive file systems
+		fromInfo, _ := os.Stat(fromFull)
+		if !os.SameFile(info, fromInfo) {
+			return nil, fmt.Errorf("%w: %s", ErrNoteExists, toRel)
+		}
+	}
+
+	// Links are resolved across the notes the policy lets us read, and only
+	// those it lets us write are rewritten
+	resolver := NewNoteResolver(w.vaultPath)
+	resolver.SetPolicy(w.policy)
+	notes, err := resolver.Notes()
+	if err != nil {
+		return nil, err
+	}
+	m := &linkMover{resolver: resolver, from: fromRel, to: toRel}
+	for _, note := range notes {
+		if note != fromRel {
+			m.after = append(m.after, note)
+		}
+	}
+	m.after = append(m.after, toRel)
+	if content, err := os.ReadFile(fromFull); err == nil {
+		if fm, _, err := ParseFrontmatter(string(content)); err == nil {
+			for _, alias := range Aliases(fm) {
+				m.aliases = append(m.aliases, strings.ToLower(alias))
+			}
+		}
+	}
+
+	result := &MoveResult{From: fromRel, To: toRel, Updated: []string{}, Skipped: []string{}}
+	updates := make(map[string]string)
+	for _, note := range notes {
+		content, err := os.ReadFile(filepath.Join(w.vaultPath, note))
+		if err != nil {
+			continue
+		}
+		newContent, count := m.rewrite(string(content), note)
+		if count == 0 {
+			continue
+		}
+		if w.policy.CheckWrite(note) != nil {
+			result.Skipped = append(result.Skipped, note)
+			continue
+		}
+		// The note may have changed while waiting for its lock
+		if err := w.lock(filepath.Join(w.vaultPath, note)); err != nil {
+			return nil, err
+		}
+		if content, err = os.ReadFile(filepath.Join(w.vaultPath, note)); err != nil {
+			continue
+		}
+		if newContent, count = m.rewrite(string(content), note); count == 0 {
+			continue
+		}
+		updates[note] = newContent
+		result.Links += count
+	}
+
+	if err := w.mkdirAll(filepath.Dir(toFull)); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := w.moveFile(fromFull, toFull, os.Rename); err != nil {
+		return nil, fmt.Errorf("failed to move note: %w", err)
+	}
+
+	for _, note := range notes {
+		content, ok := updates[note]
+		if !ok {
+			continue
+		}
+		if note == fromRel {
+			note = toRel
+		}
+		if err := w.writeFile(filepath.Join(w.vaultPath, note), []byte(content)); err != nil {
+			return result, fmt.Errorf("moved %s to %s but failed to update links in %s: %w", fromRel, toRel, note, err)
+		}
+		result.Updated = append(result.Updated, note)
+	}
+	return result, nil
+}
+
+// linkMover rewrites the links affected by moving a note
+type linkMover struct {
+	resolver *NoteResolver
+	from, to string
+	after    []string // note paths once the move is done
+	aliases  []string // lowercased aliases of the moved note
+}
+
+// rewrite updates the links in a note's content and returns the new
+// content and the number of links changed
+func (m *linkMover) rewrite(content, notePath string) (string, int) {
+	count := 0
+	content = rewriteLinks(content, func(groups []string) string {
+		target, ok := m.wikilink(groups[2], notePath)
+		if !ok {
+			return ""
+		}
+		count++
+		return groups[1] + "[[" + target + groups[3] + groups[4] + "]]"
+	}, func(groups []string) string {
+		target, ok := m.markdownLink(groups[3], notePath)
+		if !ok {
+			return ""
+		}
+		count++
+		return groups[1] + "[" + groups[2] + "](" + target + groups[4] + ")"
+	})
+	return content, count
+}
+
+// wikilink returns the new target of a wikilink written in notePath, if it changes
+func (m *linkMover) wikilink(target, notePath string) (string, bool) {
+	if strings.TrimSpace(target) == "" {
+		return "", false // [[#Heading]] links within the same note
+	}
+	resolved, ok := resolveLink(m.resolver, target, notePath)
+	if !ok {
+		return "", false
+	}
+
+	relative := strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../")
+	switch {
+	case resolved == m.from:
+		// Links through an alias keep working after the move
+		for _, alias := range m.aliases {
+			if strings.EqualFold(strings.TrimSpace(target), alias) {
+				return "", false
+			}
+		}
+		resolved = m.to
+	case notePath == m.from && relative:
+		// The moved note's own relative links now start from its new folder
+	default:
+		return "", false
+	}
+
+	source := m.moved(notePath)
+	var link string
+	switch {
+	case relative:
+		link = relativeLink(source, resolved)
+	case strings.Contains(target, "/"):
+		link = filepath.ToSlash(resolved)
+	default:
+		link = shortestLinkPath(resolved, m.after) + ".md"
+	}
+	if !strings.HasSuffix(strings.ToLower(target), ".md") {
+		link = strings.TrimSuffix(link, ".md")
+	}
+	return link, link != target
+}
+
+// markdownLink returns the new target of a markdown link written in notePath, if it changes
+func (m *linkMover) markdownLink(target, notePath string) (string, bool) {
+	parsed, ok := parseMarkdownTarget(target)
+	if !ok {
+		return "", false
+	}
+	resolved, relative := resolveMarkdownLink(m.resolver, parsed.path, notePath)
+	switch {
+	case resolved == "":
+		return "", false
+	case resolved == m.from:
+		resolved = m.to
+	case notePath == m.from && relative:
+	default:
+		return "", false
+	}
+
+	var link string
+	if relative {
+		link = relativeLink(m.moved(notePath), resolved)
+		if !strings.HasPrefix(parsed.path, "./") {
+			link = strings.TrimPrefix(link, "./")
+		}
+	} else {
+		link = filepath.ToSlash(resolved)
+		if strings.HasPrefix(parsed.path, "/") {
+			link = "/" + link
+		}
+	}
+	if !parsed.hasExt {
+		link = strings.TrimSuffix(link, ".md")
+	}
+	link += parsed.subpath
+
+	if parsed.angled {
+		link = "<" + link + ">"
+	} else {
+		link = strings.ReplaceAll(link, " ", "%20")
+	}
+	return link, link != target
+}
+
+// moved returns where a note will be after the move
+func (m *linkMover) moved(notePath string) string {
+	if notePath == m.from {
+		return m.to
+	}
+	return notePath
+}
+
+// relativeLink returns the path of target relative to the note at source
+func relativeLink(source, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(source), target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+)
+
+func TestMoveNoteRewritesLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		note string // content of Index.md, which links to Projects/Plan.md
+		dest string
+		want string
+	}{
+		{
+			name: "wikilink by name",
+			note: "See [[Plan]].\n",
+			dest: "Archive/Plan.md",
+			want: "See [[Plan]].\n",
+		},
+		{
+			name: "wikilink renamed",
+			note: "See [[Plan]] and [[Plan#Goals|goals]] and ![[Plan#^fig]].\n",
+			dest: "Projects/Roadmap.md",
+			want: "See [[Roadmap]] and [[Roadmap#Goals|goals]] and ![[Roadmap#^fig]].\n",
+		},
+		{
+			name: "wikilink by path",
+			note: "See [[Projects/Plan]].\n",
+			dest: "Archive/",
+			want: "See [[Archive/Plan]].\n",
+		},
+		{
+			name: "markdown links",
+			note: "See [plan](Projects/Plan.md#Goals) and [plan](<Projects/Plan.md>).\n",
+			dest: "Archive/Old Plan.md",
+			want: "See [plan](Archive/Old%20Plan.md#Goals) and [plan](<Archive/Old Plan.md>).\n",
+		},
+		{
+			name: "frontmatter link",
+			note: "---\nup: \"[[Plan]]\"\n---\n",
+			dest: "Projects/Roadmap.md",
+			want: "---\nup: \"[[Roadmap]]\"\n---\n",
+		},
+		{
+			name: "code is left alone",
+			note: "`[[Plan]]` and [[Plan]]\n\n````\n```\n[[Plan]]\n```\n````\n",
+			dest: "Projects/Roadmap.md",
+			want: "`[[Plan]]` and [[Roadmap]]\n\n````\n```\n[[Plan]]\n```\n````\n",
+		},
+		{
+			name: "other notes are left alone",
+			note: "[[Plans]] [[Other#Plan]] [Plan](https://example.com/Plan.md)\n",
+			dest: "Projects/Roadmap.md",
+			want: "[[Plans]] [[Other#Plan]] [Plan](https://example.com/Plan.md)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vaultPath := writeVault(t, map[string]string{
+				"Projects/Plan.md": "# Plan\n",
+				"Index.md":         tt.note,
+				"Other.md":         "",
+			})
+			if _, err := NewWriter(vaultPath).MoveNote("Projects/Plan", tt.dest); err != nil {
+				t.Fatal(err)
+			}
+			if got := readNote(t, vaultPath, "Index.md"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveNoteRelativeLinks(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Projects/Plan.md":  "See [todo](Todo.md) and [[./Todo]].\n",
+		"Projects/Todo.md":  "",
+		"Projects/Index.md": "[plan](Plan.md)\n",
+	})
+	if _, err := NewWriter(vaultPath).MoveNote("Projects/Plan", "Archive/2024/"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readNote(t, vaultPath, "Archive/2024/Plan.md"), "See [todo](../../Projects/Todo.md) and [[../../Projects/Todo]].\n"; got != want {
+		t.Errorf("moved note: got %q, want %q", got, want)
+	}
+	if got, want := readNote(t, vaultPath, "Projects/Index.md"), "[plan](../Archive/2024/Plan.md)\n"; got != want {
+		t.Errorf("linking note: got %q, want %q", got, want)
+	}
+}
+
+func TestMoveNotePolicy(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Plan.md":           "",
+		"Open.md":           "[[Plan]]\n",
+		"Locked/Note.md":    "[[Plan]]\n",
+		"Private/Secret.md": "[[Plan]]\n",
+	})
+	w := NewWriter(vaultPath)
+	w.SetPolicy(&policy.Policy{ReadDeny: []string{"Private"}, WriteDeny: []string{"Locked"}})
+
+	result, err := w.MoveNote("Plan", "Done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Open.md"}; !reflect.DeepEqual(result.Updated, want) {
+		t.Errorf("updated %v, want %v", result.Updated, want)
+	}
+	if want := []string{filepath.Join("Locked", "Note.md")}; !reflect.DeepEqual(result.Skipped, want) {
+		t.Errorf("skipped %v, want %v", result.Skipped, want)
+	}
+	for note, want := range map[string]string{
+		"Open.md":           "[[Done]]\n",
+		"Locked/Note.md":    "[[Plan]]\n",
+		"Private/Secret.md": "[[Plan]]\n",
+	} {
+		if got := readNote(t, vaultPath, note); got != want {
+			t.Errorf("%s: got %q, want %q", note, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathError reports a path that is invalid or escapes the vault
+type PathError struct {
+	Path   string
+	Reason string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("invalid path %q: %s", e.Path, e.Reason)
+}
+
+// Details returns the error as structured data for JSON and MCP clients
+func (e *PathError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   "invalid_path",
+		"path":   e.Path,
+		"reason": e.Reason,
+	}
+}
+
+// PathResolver maps user-supplied paths onto files inside the vault. It is
+// the single place where note paths are sanitized: input is normalized,
+// traversal out of the vault is rejected, and symlinks are resolved against
+// the vault root so a link inside the vault cannot reach files outside it.
+type PathResolver struct {
+	root string
+}
+
+// NewPathResolver creates a resolver rooted at the vault path
+func NewPathResolver(vaultPath string) *PathResolver {
+	return &PathResolver{root: filepath.Clean(vaultPath)}
+}
+
+// Root returns the vault root
+func (p *PathResolver) Root() string {
+	return p.root
+}
+
+// Resolve returns the absolute and vault-relative forms of a path.
+// Absolute input is accepted only if it lies inside the vault.
+func (p *PathResolver) Resolve(input string) (fullPath, relPath string, err error) {
+	if strings.TrimSpace(input) == "" {
+		return "", "", &PathError{Path: input, Reason: "path is empty"}
+	}
+	if strings.ContainsRune(input, 0) {
+		return "", "", &PathError{Path: input, Reason: "path contains a NUL byte"}
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(input))
+	if filepath.IsAbs(cleaned) {
+		rel, err := filepath.Rel(p.root, cleaned)
+		if err != nil || escapes(rel) {
+			return "", "", &PathError{Path: input, Reason: "absolute path is outside the vault"}
+		}
+		cleaned = rel
+	}
+	if escapes(cleaned) {
+		return "", "", &PathError{Path: input, Reason: "path escapes the vault"}
+	}
+	if cleaned == "." {
+		return "", "", &PathError{Path: input, Reason: "path refers to the vault root"}
+	}
+
+	fullPath = filepath.Join(p.root, cleaned)
+	if err := p.Contain(fullPath); err != nil {
+		return "", "", &PathError{Path: input, Reason: err.(*PathError).Reason}
+	}
+	return fullPath, cleaned, nil
+}
+
+// ResolveNote resolves a note path, adding the .md extension if missing
+func (p *PathResolver) ResolveNote(input string) (fullPath, relPath string, err error) {
+	if !strings.HasSuffix(input, ".md") {
+		input += ".md"
+	}
+	return p.Resolve(input)
+}
+
+// Contain verifies that an absolute path inside the vault, after following
+// symlinks, still lands inside the vault. The path need not exist yet; its
+// deepest existing ancestor is resolved instead.
+func (p *PathResolver) Contain(fullPath string) error {
+	realRoot, err := filepath.EvalSymlinks(p.root)
+	if err != nil {
+		return &PathError{Path: fullPath, Reason: fmt.Sprintf("cannot resolve vault root: %v", err)}
+	}
+
+	realPath, err := evalExisting(fullPath)
+	if err != nil {
+		return &PathError{Path: fullPath, Reason: fmt.Sprintf("cannot resolve path: %v", err)}
+	}
+
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || escapes(rel) {
+		return &PathError{Path: fullPath, Reason: "symlink points outside the vault"}
+	}
+	return nil
+}
+
+// Rel returns the vault-relative form of an absolute path inside the vault
+func (p *PathResolver) Rel(fullPath string) (string, error) {
+	rel, err := filepath.Rel(p.root, fullPath)
+	if err != nil || escapes(rel) {
+		return "", &PathError{Path: fullPath, Reason: "path is outside the vault"}
+	}
+	return rel, nil
+}
+
+// maxDanglingLinks bounds the chain of dangling symlinks evalExisting follows
+const maxDanglingLinks = 40
+
+// evalExisting resolves symlinks in the longest existing prefix of path and
+// re-appends the components that don't exist yet. A dangling symlink is
+// followed to where it points, since writing through it would create its
+// target.
+func evalExisting(path string) (string, error) {
+	var missing []string
+	current := path
+	links := 0
+	for {
+		real, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				real = filepath.Join(real, missing[i])
+			}
+			return real, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if info, lerr := os.Lstat(current); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(current)
+			if err != nil {
+				return "", err
+			}
+			if links++; links > maxDanglingLinks {
+				return "", fmt.Errorf("too many links in %s", path)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(current), target)
+			}
+			current = target
+			continue
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(current))
+		current = parent
+	}
+}
+
+// escapes reports whether a cleaned relative path leaves its base directory
+func escapes(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel)
+}
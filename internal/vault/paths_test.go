@@ -0,0 +1,171 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeVault creates a temporary vault holding files, keyed by
+// slash-separated vault-relative path
+func writeVault(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// readNote returns a note's content from a test vault
+func readNote(t *testing.T, vaultPath, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(vaultPath, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// symlink creates a link at a vault-relative path, skipping the test where
+// the platform doesn't allow it
+func symlink(t *testing.T, vaultPath, target, name string) {
+	t.Helper()
+	if err := os.Symlink(target, filepath.Join(vaultPath, filepath.FromSlash(name))); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+}
+
+func TestPathResolverResolve(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vaultPath := writeVault(t, map[string]string{
+		"Notes/a.md": "a",
+	})
+	symlink(t, vaultPath, outside, "Escape")
+	symlink(t, vaultPath, filepath.Join(outside, "secret.md"), "Secret.md")
+	symlink(t, vaultPath, filepath.Join(vaultPath, "Notes"), "Alias")
+
+	tests := []struct {
+		input string
+		want  string // vault-relative, slash-separated
+		err   string // part of the PathError reason
+	}{
+		{input: "Notes/a.md", want: "Notes/a.md"},
+		{input: "Notes/../Notes/./a.md", want: "Notes/a.md"},
+		{input: "New/b.md", want: "New/b.md"},
+		{input: filepath.Join(vaultPath, "Notes", "a.md"), want: "Notes/a.md"},
+		{input: "Alias/a.md", want: "Alias/a.md"},
+		{input: "Alias/new/deep.md", want: "Alias/new/deep.md"},
+		{input: "", err: "empty"},
+		{input: ".", err: "vault root"},
+		{input: "Notes/..", err: "vault root"},
+		{input: "../outside.md", err: "escapes the vault"},
+		{input: "Notes/../../outside.md", err: "escapes the vault"},
+		{input: "..", err: "escapes the vault"},
+		{input: filepath.Join(outside, "secret.md"), err: "outside the vault"},
+		{input: filepath.Join(vaultPath, "..", "x.md"), err: "outside the vault"},
+		{input: "Notes/a\x00.md", err: "NUL byte"},
+		{input: "Escape/secret.md", err: "symlink points outside"},
+		{input: "Escape", err: "symlink points outside"},
+		{input: "Secret.md", err: "symlink points outside"},
+		{input: "Escape/new/deep.md", err: "symlink points outside"},
+	}
+	resolver := NewPathResolver(vaultPath)
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			full, rel, err := resolver.Resolve(tt.input)
+			if tt.err != "" {
+				var pathErr *PathError
+				if !errors.As(err, &pathErr) || !strings.Contains(pathErr.Reason, tt.err) {
+					t.Fatalf("got %q, %v, want an error containing %q", rel, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.ToSlash(rel) != tt.want {
+				t.Errorf("relative path %q, want %q", rel, tt.want)
+			}
+			if full != filepath.Join(vaultPath, rel) {
+				t.Errorf("full path %q is not the relative path in the vault", full)
+			}
+		})
+	}
+}
+
+func TestPathResolverResolveNote(t *testing.T) {
+	resolver := NewPathResolver(t.TempDir())
+	for input, want := range map[string]string{
+		"Plan":        "Plan.md",
+		"Plan.md":     "Plan.md",
+		"Daily/2024":  "Daily/2024.md",
+		"v1.2 Review": "v1.2 Review.md",
+	} {
+		_, rel, err := resolver.ResolveNote(input)
+		if err != nil {
+			t.Fatalf("ResolveNote(%q): %v", input, err)
+		}
+		if filepath.ToSlash(rel) != want {
+			t.Errorf("ResolveNote(%q) = %q, want %q", input, rel, want)
+		}
+	}
+}
+
+func TestPathResolverSymlinkedRoot(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{"a.md": "a"})
+	link := filepath.Join(t.TempDir(), "vault")
+	if err := os.Symlink(vaultPath, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	resolver := NewPathResolver(link)
+	for _, input := range []string{"a.md", "New/b.md"} {
+		if _, _, err := resolver.Resolve(input); err != nil {
+			t.Errorf("Resolve(%q) through a symlinked vault: %v", input, err)
+		}
+	}
+}
+
+func TestPathResolverRel(t *testing.T) {
+	vaultPath := t.TempDir()
+	resolver := NewPathResolver(vaultPath)
+	rel, err := resolver.Rel(filepath.Join(vaultPath, "Notes", "a.md"))
+	if err != nil || filepath.ToSlash(rel) != "Notes/a.md" {
+		t.Errorf("got %q, %v, want Notes/a.md", rel, err)
+	}
+	if _, err := resolver.Rel(filepath.Dir(vaultPath)); err == nil {
+		t.Error("got no error for a path outside the vault")
+	}
+}
+
+func TestPathResolverDanglingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	vaultPath := writeVault(t, map[string]string{"Notes/a.md": "a"})
+	symlink(t, vaultPath, filepath.Join(outside, "missing.md"), "Dangling.md")
+	symlink(t, vaultPath, filepath.Join(outside, "missing"), "Gone")
+	symlink(t, vaultPath, filepath.Join(vaultPath, "Notes", "new.md"), "Inside.md")
+	symlink(t, vaultPath, "Notes/later.md", "Relative.md")
+
+	resolver := NewPathResolver(vaultPath)
+	for _, input := range []string{"Dangling.md", "Gone/new.md"} {
+		if _, _, err := resolver.Resolve(input); err == nil {
+			t.Errorf("Resolve(%q) followed a dangling symlink out of the vault", input)
+		}
+	}
+	for _, input := range []string{"Inside.md", "Relative.md"} {
+		if _, _, err := resolver.Resolve(input); err != nil {
+			t.Errorf("Resolve(%q) of a dangling symlink inside the vault: %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is the span of time a periodic note covers
+type Period string
+
+const (
+	PeriodDay     Period = "day"
+	PeriodWeek    Period = "week"
+	PeriodMonth   Period = "month"
+	PeriodQuarter Period = "quarter"
+	PeriodYear    Period = "year"
+)
+
+// Periods lists every supported period, shortest first
+var Periods = []Period{PeriodDay, PeriodWeek, PeriodMonth, PeriodQuarter, PeriodYear}
+
+// DefaultDailyNoteFormat is Obsidian's default daily note filename format
+const DefaultDailyNoteFormat = "YYYY-MM-DD"
+
+// defaultFormats are the filename formats used when a period isn't configured.
+// Weekly notes use ISO weeks.
+var defaultFormats = map[Period]string{
+	PeriodDay:     DefaultDailyNoteFormat,
+	PeriodWeek:    "GGGG-[W]WW",
+	PeriodMonth:   "YYYY-MM",
+	PeriodQuarter: "YYYY-[Q]Q",
+	PeriodYear:    "YYYY",
+}
+
+// settingsKeys are the Periodic Notes plugin's keys for each period
+var settingsKeys = map[Period]string{
+	PeriodDay:     "daily",
+	PeriodWeek:    "weekly",
+	PeriodMonth:   "monthly",
+	PeriodQuarter: "quarterly",
+	PeriodYear:    "yearly",
+}
+
+// legacyDailyNotePaths are tried when the vault has no daily notes settings
+var legacyDailyNotePaths = []string{
+	"Rough Notes/%s.md",
+	"Daily/%s.md",
+	"%s.md",
+}
+
+// ParsePeriod parses a period name such as "week", "weekly" or "w"
+func ParsePeriod(s string) (Period, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "day", "daily", "d":
+		return PeriodDay, nil
+	case "week", "weekly", "w":
+		return PeriodWeek, nil
+	case "month", "monthly", "m":
+		return PeriodMonth, nil
+	case "quarter", "quarterly", "q":
+		return PeriodQuarter, nil
+	case "year", "yearly", "y":
+		return PeriodYear, nil
+	}
+	return "", fmt.Errorf("unknown period %q (use day, week, month, quarter or year)", s)
+}
+
+// Start returns the first day of the period containing t. Weeks start on Monday.
+func (p Period) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch p {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	case PeriodQuarter:
+		return time.Date(t.Year(), time.Month((quarter(t)-1)*3+1), 1, 0, 0, 0, 0, t.Location())
+	case PeriodYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// Shift moves t by n periods, returning the start of the resulting period
+func (p Period) Shift(t time.Time, n int) time.Time {
+	start := p.Start(t)
+	switch p {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonth:
+		return start.AddDate(0, n, 0)
+	case PeriodQuarter:
+		return start.AddDate(0, 3*n, 0)
+	case PeriodYear:
+		return start.AddDate(n, 0, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+var (
+	isoWeekRegex = regexp.MustCompile(`^(\d{4})-?W(\d{1,2})$`)
+	monthRegex   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	quarterRegex = regexp.MustCompile(`^(\d{4})-?Q([1-4])$`)
+	yearRegex    = regexp.MustCompile(`^(\d{4})$`)
+)
+
+// ParsePeriodDate resolves a date expression relative to now. It accepts
+// "today"/"this", "last"/"previous", "next", YYYY-MM-DD, and the period
+// forms YYYY-Www, YYYY-MM, YYYY-Qn and YYYY. An empty string means now.
+func ParsePeriodDate(p Period, expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	switch strings.ToLower(expr) {
+	case "", "today", "this", "current", "now":
+		return now, nil
+	case "last", "previous", "prev":
+		return p.Shift(now, -1), nil
+	case "next":
+		return p.Shift(now, 1), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", expr, now.Location()); err == nil {
+		return t, nil
+	}
+	if m := isoWeekRegex.FindStringSubmatch(strings.ToUpper(expr)); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		if week < 1 || week > 53 {
+			return time.Time{}, fmt.Errorf("invalid week %q", expr)
+		}
+		// January 4th is always in ISO week 1
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, now.Location())
+		return PeriodWeek.Start(jan4).AddDate(0, 0, 7*(week-1)), nil
+	}
+	if m := monthRegex.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 {
+			return time.Time{}, fmt.Errorf("invalid month %q", expr)
+		}
+		return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if m := quarterRegex.FindStringSubmatch(strings.ToUpper(expr)); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		return time.Date(year, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if m := yearRegex.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD, YYYY-Www, YYYY-MM, YYYY-Qn, YYYY, last or next", expr)
+}
+
+// PeriodicNoteConfig holds the settings for one kind of periodic note, as
+// configured in Obsidian's core Daily Notes plugin or the Periodic Notes plugin
+type PeriodicNoteConfig struct {
+	// Folder is the vault-relative folder new notes are created in
+	Folder string `json:"folder"`
+	// Format is a moment.js format string for the note's path within Folder
+	Format string `json:"format"`
+	// Template is the vault-relative path of the template note, if any
+	Template string `json:"template"`
+}
+
+// periodicNotesSettings is the subset of the Periodic Notes plugin's data.json we read
+type periodicNotesSettings map[string]*struct {
+	PeriodicNoteConfig
+	Enabled bool `json:"enabled"`
+}
+
+// LoadPeriodicNoteConfig reads the vault's settings for a period. Periodic
+// Notes takes precedence over the core Daily Notes plugin when the period is
+// enabled there. For daily notes it returns nil if neither plugin is
+// configured; other periods fall back to the vault root and a default format.
+func LoadPeriodicNoteConfig(vaultPath string, p Period) (*PeriodicNoteConfig, error) {
+	obsidianDir := filepath.Join(vaultPath, ".obsidian")
+
+	var periodic periodicNotesSettings
+	found, err := readJSONConfig(filepath.Join(obsidianDir, "plugins", "periodic-notes", "data.json"), &periodic)
+	if err != nil {
+		return nil, err
+	}
+	if settings := periodic[settingsKeys[p]]; found && settings != nil && settings.Enabled {
+		return settings.PeriodicNoteConfig.withDefaults(p), nil
+	}
+
+	if p != PeriodDay {
+		return PeriodicNoteConfig{}.withDefaults(p), nil
+	}
+
+	var core PeriodicNoteConfig
+	found, err = readJSONConfig(filepath.Join(obsidianDir, "daily-notes.json"), &core)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return core.withDefaults(p), nil
+	}
+	return nil, nil
+}
+
+// withDefaults fills in Obsidian's defaults for unset fields
+func (c PeriodicNoteConfig) withDefaults(p Period) *PeriodicNoteConfig {
+	c.Folder = strings.Trim(strings.TrimSpace(c.Folder), "/")
+	c.Format = strings.TrimSpace(c.Format)
+	if c.Format == "" {
+		c.Format = defaultFormats[p]
+	}
+	c.Template = strings.TrimSpace(c.Template)
+	return &c
+}
+
+// NotePath returns the vault-relative path of the note for a date
+func (c *PeriodicNoteConfig) NotePath(date time.Time) string {
+	return path.Join(c.Folder, FormatMoment(date, c.Format)) + ".md"
+}
+
+// readJSONConfig decodes a JSON settings file, reporting whether it exists
+func readJSONConfig(file string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %w", filepath.Base(file), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+	return true, nil
+}
+
+// periodicNoteCandidates returns the paths a periodic note may live at, most
+// preferred first. New notes are created at the first candidate.
+func periodicNoteCandidates(vaultPath string, p Period, date time.Time) ([]string, *PeriodicNoteConfig, error) {
+	config, err := LoadPeriodicNoteConfig(vaultPath, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config != nil {
+		return []string{config.NotePath(p.Start(date))}, config, nil
+	}
+
+	dateStr := date.Format("2006-01-02")
+	candidates := make([]string, len(legacyDailyNotePaths))
+	for i, format := range legacyDailyNotePaths {
+		candidates[i] = fmt.Sprintf(format, dateStr)
+	}
+	return candidates, nil, nil
+}
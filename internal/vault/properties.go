@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Date is a date-only property value. It is written to frontmatter as an
+// unquoted YYYY-MM-DD, which Obsidian shows as a Date property.
+type Date struct {
+	time.Time
+}
+
+// String formats the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Format("2006-01-02")
+}
+
+// MarshalYAML writes the date as a plain YAML timestamp
+func (d Date) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: d.String()}, nil
+}
+
+// MarshalJSON writes the date as a YYYY-MM-DD string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// ParsePropertyValue converts text into a typed property value using YAML
+// syntax: "3" is a number, "true" a boolean, "[a, b]" a list and
+// "2025-01-31" a date. Anything else is kept as a string.
+func ParsePropertyValue(s string) (interface{}, error) {
+	value := parseYAMLValue(s)
+	if _, ok := asMap(value); ok {
+		// A stray colon shouldn't turn a value into an object
+		return s, nil
+	}
+	return value, nil
+}
+
+// parseYAMLValue decodes text as a YAML value, falling back to the text itself
+func parseYAMLValue(s string) interface{} {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		// Not valid YAML (e.g. "a: b: c"), so take it literally
+		return s
+	}
+	if t, ok := value.(time.Time); ok && !strings.Contains(s, "T") && isMidnightUTC(t) {
+		return Date{t}
+	}
+	return value
+}
+
+// isMidnightUTC reports whether t carries no time of day
+func isMidnightUTC(t time.Time) bool {
+	return t.Location() == time.UTC && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// normalizeDates converts the date-only timestamps yaml.v3 decodes into
+// Date values, so they are written back as YYYY-MM-DD rather than RFC 3339
+func normalizeDates(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if isMidnightUTC(v) {
+			return Date{v}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeDates(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalizeDates(v[k])
+		}
+	case Frontmatter:
+		for k := range v {
+			v[k] = normalizeDates(v[k])
+		}
+	}
+	return value
+}
+
+// ParseProperty parses a key=value assignment into a property name and typed value
+func ParseProperty(assignment string) (string, interface{}, error) {
+	key, raw, ok := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid property %q, use key=value", assignment)
+	}
+	value, err := ParsePropertyValue(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, value, nil
+}
@@ -0,0 +1,353 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PropertyType is an Obsidian property type, as set in .obsidian/types.json
+type PropertyType string
+
+const (
+	PropertyText     PropertyType = "text"
+	PropertyList     PropertyType = "multitext"
+	PropertyNumber   PropertyType = "number"
+	PropertyCheckbox PropertyType = "checkbox"
+	PropertyDate     PropertyType = "date"
+	PropertyDateTime PropertyType = "datetime"
+	PropertyTags     PropertyType = "tags"
+	PropertyAliases  PropertyType = "aliases"
+)
+
+// datetimeFormat is how Obsidian writes Date & time properties
+const datetimeFormat = "2006-01-02T15:04"
+
+var (
+	dateStringRegex     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	datetimeStringRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}`)
+)
+
+// ParsePropertyType parses a property type by its Obsidian name, or "list" for multitext
+func ParsePropertyType(s string) (PropertyType, error) {
+	switch t := PropertyType(strings.ToLower(strings.TrimSpace(s))); t {
+	case "list":
+		return PropertyList, nil
+	case PropertyText, PropertyList, PropertyNumber, PropertyCheckbox, PropertyDate, PropertyDateTime, PropertyTags, PropertyAliases:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown property type %q (use text, list, number, checkbox, date or datetime)", s)
+}
+
+// IsList reports whether values of the type are lists
+func (t PropertyType) IsList() bool {
+	return t == PropertyList || t == PropertyTags || t == PropertyAliases
+}
+
+// PropertyTypes maps property names to their declared types
+type PropertyTypes map[string]PropertyType
+
+// LoadPropertyTypes reads the property types set in Obsidian from
+// .obsidian/types.json. tags and aliases are always lists.
+func LoadPropertyTypes(vaultPath string) (PropertyTypes, error) {
+	var config struct {
+		Types map[string]string `json:"types"`
+	}
+	types := PropertyTypes{"tags": PropertyTags, "aliases": PropertyAliases}
+	if _, err := readJSONConfig(filepath.Join(vaultPath, ".obsidian", "types.json"), &config); err != nil {
+		return nil, err
+	}
+	for name, t := range config.Types {
+		// Types added by plugins are left for Obsidian to interpret
+		if typ, err := ParsePropertyType(t); err == nil {
+			types[name] = typ
+		}
+	}
+	return types, nil
+}
+
+// ConvertPropertyValue converts text to a value of the given type. Lists
+// take YAML flow syntax ("[a, b]") or a single item; dates are YYYY-MM-DD
+// and date-times YYYY-MM-DDTHH:MM.
+func ConvertPropertyValue(typ PropertyType, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch typ {
+	case PropertyText:
+		return raw, nil
+	case PropertyNumber:
+		if i, err := strconv.Atoi(raw); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return f, nil
+	case PropertyCheckbox:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a checkbox value, use true or false", raw)
+		}
+		return b, nil
+	case PropertyDate:
+		d, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date, use YYYY-MM-DD", raw)
+		}
+		return Date{d}, nil
+	case PropertyDateTime:
+		for _, layout := range []string{datetimeFormat, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339} {
+			if d, err := time.Parse(layout, raw); err == nil {
+				if d.Second() != 0 {
+					return d.Format("2006-01-02T15:04:05"), nil
+				}
+				return d.Format(datetimeFormat), nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date and time, use YYYY-MM-DDTHH:MM", raw)
+	}
+
+	// Lists
+	var items []interface{}
+	if strings.HasPrefix(raw, "[") {
+		list, ok := parseYAMLValue(raw).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%q is not a list, use [a, b]", raw)
+		}
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+	} else if raw != "" {
+		items = []interface{}{raw}
+	}
+	if typ == PropertyTags {
+		for i, item := range items {
+			items[i] = strings.TrimPrefix(item.(string), "#")
+		}
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+	return items, nil
+}
+
+// AddPropertyValues appends values to a list property, creating it or
+// turning a single value into a list, and skips values already in it. It
+// returns the note's version and the number of values added.
+func (w *Writer) AddPropertyValues(name, key string, values []interface{}, expectedVersion string) (version string, added int, err error) {
+	w, done := w.begin("frontmatter")
+	defer done(&err)
+
+	version, err = w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		fm, err := EditFrontmatter(content)
+		if err != nil {
+			return "", err
+		}
+		current, _ := fm.Get(key)
+		list := propertyList(current)
+		for _, value := range values {
+			if indexOfValue(key, list, value) < 0 {
+				list = append(list, value)
+				added++
+			}
+		}
+		if added == 0 {
+			return content, nil
+		}
+		if err := fm.Set(key, list); err != nil {
+			return "", err
+		}
+		return fm.String(), nil
+	})
+	return version, added, err
+}
+
+// RemovePropertyValues removes values from a list property and returns the
+// note's version and the number of values removed. tags and aliases match
+// regardless of case.
+func (w *Writer) RemovePropertyValues(name, key string, values []interface{}, expectedVersion string) (version string, removed int, err error) {
+	w, done := w.begin("frontmatter")
+	defer done(&err)
+
+	version, err = w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		fm, err := EditFrontmatter(content)
+		if err != nil {
+			return "", err
+		}
+		current, ok := fm.Get(key)
+		if !ok {
+			return content, nil
+		}
+		list := propertyList(current)
+		for _, value := range values {
+			for i := indexOfValue(key, list, value); i >= 0; i = indexOfValue(key, list, value) {
+				list = append(list[:i], list[i+1:]...)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return content, nil
+		}
+		if err := fm.Set(key, list); err != nil {
+			return "", err
+		}
+		return fm.String(), nil
+	})
+	return version, removed, err
+}
+
+// UnsetProperty removes a property from a note and returns the note's
+// version and whether the property was there
+func (w *Writer) UnsetProperty(name, key, expectedVersion string) (version string, found bool, err error) {
+	w, done := w.begin("frontmatter")
+	defer done(&err)
+
+	version, err = w.rewriteNote(name, expectedVersion, func(content string) (string, error) {
+		fm, err := EditFrontmatter(content)
+		if err != nil {
+			return "", err
+		}
+		if found, err = fm.Delete(key); err != nil || !found {
+			return content, err
+		}
+		return fm.String(), nil
+	})
+	return version, found, err
+}
+
+// propertyList returns a property value as a list
+func propertyList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return v
+	}
+	return []interface{}{value}
+}
+
+// indexOfValue finds a value in a list property. Tags match without their
+// leading # and, like aliases, regardless of case.
+func indexOfValue(key string, list []interface{}, value interface{}) int {
+	normalize := func(v interface{}) string {
+		s := fmt.Sprint(v)
+		switch strings.ToLower(key) {
+		case "tags":
+			return strings.ToLower(strings.TrimPrefix(s, "#"))
+		case "aliases":
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	want := normalize(value)
+	for i, item := range list {
+		if normalize(item) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// PropertyInfo describes a property used in the vault
+type PropertyInfo struct {
+	Name string       `json:"name"`
+	Type PropertyType `json:"type"`
+	// Declared is true when the type comes from .obsidian/types.json
+	// rather than being inferred from the values
+	Declared bool `json:"declared"`
+	// Count is the number of notes with the property
+	Count int `json:"count"`
+}
+
+// ListProperties lists every frontmatter property in the vault with its
+// type and the number of notes using it, most used first
+func (r *Reader) ListProperties() ([]PropertyInfo, error) {
+	types, err := LoadPropertyTypes(r.vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	resolver := NewNoteResolver(r.vaultPath)
+	resolver.SetPolicy(r.policy)
+	notes, err := resolver.Notes()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	inferred := make(map[string]map[PropertyType]int)
+	for _, note := range notes {
+		content, err := os.ReadFile(filepath.Join(r.vaultPath, note))
+		if err != nil {
+			continue
+		}
+		fm, _, err := ParseFrontmatter(string(content))
+		if err != nil {
+			continue
+		}
+		for key, value := range fm {
+			counts[key]++
+			if typ := inferPropertyType(value); typ != "" {
+				if inferred[key] == nil {
+					inferred[key] = make(map[PropertyType]int)
+				}
+				inferred[key][typ]++
+			}
+		}
+	}
+
+	properties := make([]PropertyInfo, 0, len(counts))
+	for key, count := range counts {
+		info := PropertyInfo{Name: key, Type: PropertyText, Count: count}
+		if typ, ok := types[key]; ok {
+			info.Type, info.Declared = typ, true
+		} else {
+			// The type most of the notes' values have
+			best := 0
+			for typ, n := range inferred[key] {
+				if n > best || (n == best && typ < info.Type) {
+					info.Type, best = typ, n
+				}
+			}
+		}
+		properties = append(properties, info)
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		if properties[i].Count != properties[j].Count {
+			return properties[i].Count > properties[j].Count
+		}
+		return properties[i].Name < properties[j].Name
+	})
+	return properties, nil
+}
+
+// inferPropertyType guesses a property's type from a value, or returns ""
+// for empty values
+func inferPropertyType(value interface{}) PropertyType {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return PropertyCheckbox
+	case int, int64, uint64, float64:
+		return PropertyNumber
+	case []interface{}:
+		return PropertyList
+	case Date:
+		return PropertyDate
+	case time.Time:
+		return PropertyDateTime
+	case string:
+		switch {
+		case datetimeStringRegex.MatchString(v):
+			return PropertyDateTime
+		case dateStringRegex.MatchString(v):
+			return PropertyDate
+		}
+	}
+	return PropertyText
+}
@@ -6,29 +6,64 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
 )
 
 type Reader struct {
 	vaultPath string
+	paths     *PathResolver
+	policy    *policy.Policy
+	locker    *NoteLocker
 }
 
 func NewReader(vaultPath string) *Reader {
-	return &Reader{vaultPath: vaultPath}
+	return &Reader{vaultPath: vaultPath, paths: NewPathResolver(vaultPath)}
+}
+
+// SetPolicy restricts which notes the reader may access (nil allows all)
+func (r *Reader) SetPolicy(p *policy.Policy) {
+	r.policy = p
 }
 
-// ReadNote reads a note by filename
+// Paths returns the resolver used to sandbox note paths
+func (r *Reader) Paths() *PathResolver {
+	return r.paths
+}
+
+// ResolveNote returns the vault-relative path of the note a name refers to,
+// resolving bare names and aliases the way Obsidian resolves [[links]]
+func (r *Reader) ResolveNote(name string) (string, error) {
+	return resolveExisting(r.vaultPath, r.paths, r.policy, name)
+}
+
+// ReadNote reads a note by path, name or alias
 func (r *Reader) ReadNote(filename string) (string, error) {
-	fullPath := filepath.Join(r.vaultPath, filename)
-	if !strings.HasSuffix(fullPath, ".md") {
-		fullPath += ".md"
+	_, content, _, err := r.ReadNoteVersion(filename)
+	return content, err
+}
+
+// ReadNoteVersion reads a note by path, name or alias, also returning its
+// vault-relative path and version token for use with expected versions
+func (r *Reader) ReadNoteVersion(filename string) (relPath, content, version string, err error) {
+	notePath, err := r.ResolveNote(filename)
+	if err != nil {
+		return "", "", "", err
+	}
+	fullPath, relPath, err := r.paths.Resolve(notePath)
+	if err != nil {
+		return "", "", "", err
+	}
+	if err := r.policy.CheckRead(relPath); err != nil {
+		return "", "", "", err
 	}
 
-	content, err := os.ReadFile(fullPath)
+	data, err := r.readLocked(fullPath, relPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read note: %w", err)
+		return "", "", "", fmt.Errorf("failed to read note: %w", err)
 	}
 
-	return string(content), nil
+	return relPath, string(data), ContentVersion(data), nil
 }
 
 // SearchNotes performs a simple text search across all markdown files
@@ -50,13 +85,17 @@ func (r *Reader) SearchNotes(query string) ([]string, error) {
 
 		// Only process markdown files
 		if !info.IsDir() && strings.HasSuffix(info.Name(), ".md") {
+			relPath, _ := filepath.Rel(r.vaultPath, path)
+			if !r.policy.CanRead(relPath) || r.paths.Contain(path) != nil {
+				return nil
+			}
+
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return nil // Skip files we can't read
 			}
 
 			if strings.Contains(strings.ToLower(string(content)), strings.ToLower(query)) {
-				relPath, _ := filepath.Rel(r.vaultPath, path)
 				results = append(results, relPath)
 			}
 		}
@@ -69,45 +108,65 @@ func (r *Reader) SearchNotes(query string) ([]string, error) {
 
 // GetDailyNote returns the daily note for a given date
 func (r *Reader) GetDailyNote(date string) (string, error) {
-	// Parse date or use today
-	var targetDate time.Time
-	var err error
-
-	if date == "" || date == "today" {
-		targetDate = time.Now()
-	} else {
-		targetDate, err = time.Parse("2006-01-02", date)
-		if err != nil {
-			return "", fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
-		}
+	targetDate, err := ParsePeriodDate(PeriodDay, date, time.Now())
+	if err != nil {
+		return "", err
 	}
+	return r.GetPeriodicNote(PeriodDay, targetDate)
+}
 
-	// Common daily note formats
-	formats := []string{
-		"Rough Notes/%s.md",
-		"Daily/%s.md",
-		"%s.md",
+// GetPeriodicNote returns the note for the period containing date
+func (r *Reader) GetPeriodicNote(period Period, date time.Time) (string, error) {
+	fullPath, relPath, found, err := r.findPeriodicNote(period, date)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("%s note not found: %s", settingsKeys[period], relPath)
 	}
 
-	dateStr := targetDate.Format("2006-01-02")
+	if err := r.policy.CheckRead(relPath); err != nil {
+		return "", err
+	}
+	content, err := r.readLocked(fullPath, relPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read note: %w", err)
+	}
+	return string(content), nil
+}
 
-	for _, format := range formats {
-		filename := fmt.Sprintf(format, dateStr)
-		fullPath := filepath.Join(r.vaultPath, filename)
+// PeriodicNotePath returns the vault-relative path of the note for the period
+// containing date: where it exists, or where it would be created
+func (r *Reader) PeriodicNotePath(period Period, date time.Time) (string, error) {
+	_, relPath, _, err := r.findPeriodicNote(period, date)
+	return relPath, err
+}
 
-		if _, err := os.Stat(fullPath); err == nil {
-			content, err := os.ReadFile(fullPath)
-			if err != nil {
-				return "", err
-			}
-			return string(content), nil
-		}
+// findPeriodicNote locates a periodic note, returning the preferred location
+// with found=false if it doesn't exist yet
+func (r *Reader) findPeriodicNote(period Period, date time.Time) (fullPath, relPath string, found bool, err error) {
+	candidates, _, err := periodicNoteCandidates(r.vaultPath, period, date)
+	if err != nil {
+		return "", "", false, err
 	}
 
-	return "", fmt.Errorf("daily note not found for %s", dateStr)
+	for i, candidate := range candidates {
+		candidatePath, candidateRel, err := r.paths.Resolve(candidate)
+		if err != nil {
+			return "", "", false, err
+		}
+		if i == 0 {
+			fullPath, relPath = candidatePath, candidateRel
+		}
+		if _, err := os.Stat(candidatePath); err == nil {
+			return candidatePath, candidateRel, true, nil
+		}
+	}
+	return fullPath, relPath, false, nil
 }
 
-// ListTags extracts all unique tags from the vault
+// ListTags extracts all unique tags from the vault: those in tags
+// properties and the inline #tags outside code
 func (r *Reader) ListTags() ([]string, error) {
 	tagSet := make(map[string]bool)
 
@@ -124,21 +183,17 @@ func (r *Reader) ListTags() ([]string, error) {
 		}
 
 		if !info.IsDir() && strings.HasSuffix(info.Name(), ".md") {
+			if relPath, _ := filepath.Rel(r.vaultPath, path); !r.policy.CanRead(relPath) || r.paths.Contain(path) != nil {
+				return nil
+			}
+
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return nil
 			}
 
-			// Extract #tags
-			words := strings.Fields(string(content))
-			for _, word := range words {
-				if strings.HasPrefix(word, "#") {
-					tag := strings.TrimPrefix(word, "#")
-					tag = strings.Trim(tag, ".,!?;:")
-					if tag != "" {
-						tagSet[tag] = true
-					}
-				}
+			for _, tag := range ParseDocument(string(content)).AllTags() {
+				tagSet[tag] = true
 			}
 		}
 
@@ -0,0 +1,289 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+)
+
+// ErrNoteNotFound is returned when a note name resolves to no note
+var ErrNoteNotFound = errors.New("note not found")
+
+// AmbiguousNoteError reports a note name that matches several notes
+type AmbiguousNoteError struct {
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousNoteError) Error() string {
+	return fmt.Sprintf("note %q is ambiguous, it matches: %s", e.Name, strings.Join(e.Candidates, ", "))
+}
+
+// Details returns the error as structured data for JSON and MCP clients
+func (e *AmbiguousNoteError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":       "ambiguous_note",
+		"name":       e.Name,
+		"candidates": e.Candidates,
+	}
+}
+
+// NoteResolver resolves note names the way Obsidian resolves [[links]]:
+// by exact path, by unique basename anywhere in the vault, by the shortest
+// unambiguous path suffix, and by the aliases frontmatter property.
+// Matching is case-insensitive and the .md extension is optional.
+type NoteResolver struct {
+	vaultPath string
+	paths     *PathResolver
+	policy    *policy.Policy
+
+	loaded  bool
+	notes   []string            // vault-relative note paths
+	byPath  map[string][]string // lowercased slash path without .md
+	byName  map[string][]string // lowercased basename without .md
+	byAlias map[string][]string // lowercased alias, built on first use
+}
+
+// NewNoteResolver creates a resolver for the vault. The vault is scanned on first use.
+func NewNoteResolver(vaultPath string) *NoteResolver {
+	return &NoteResolver{vaultPath: vaultPath, paths: NewPathResolver(vaultPath)}
+}
+
+// SetPolicy hides notes the policy doesn't allow reading (nil allows all)
+func (n *NoteResolver) SetPolicy(p *policy.Policy) {
+	n.policy = p
+}
+
+// Notes returns the vault-relative path of every resolvable note
+func (n *NoteResolver) Notes() ([]string, error) {
+	if err := n.load(); err != nil {
+		return nil, err
+	}
+	return n.notes, nil
+}
+
+// Resolve returns the vault-relative path of the note a name refers to
+func (n *NoteResolver) Resolve(name string) (string, error) {
+	return n.ResolveFrom(name, "")
+}
+
+// ResolveFrom resolves a name as written in the note at sourcePath, so
+// relative links work and, among equally good matches, notes in the
+// source's folder win. Link syntax such as "Note#Heading|alias" is accepted.
+func (n *NoteResolver) ResolveFrom(name, sourcePath string) (string, error) {
+	if err := n.load(); err != nil {
+		return "", err
+	}
+
+	linkPath := linkPathOf(name)
+	if linkPath == "" {
+		return "", fmt.Errorf("%w: %q", ErrNoteNotFound, name)
+	}
+
+	// Relative links are resolved against the source note's folder only
+	if sourcePath != "" && (strings.HasPrefix(linkPath, "./") || strings.HasPrefix(linkPath, "../")) {
+		joined := path.Join(path.Dir(filepath.ToSlash(sourcePath)), linkPath)
+		return n.pick(name, sourcePath, n.byPath[noteKey(joined)])
+	}
+	linkPath = strings.TrimPrefix(linkPath, "/")
+	key := noteKey(linkPath)
+
+	if matches := n.byPath[key]; len(matches) > 0 {
+		return n.pick(name, sourcePath, matches)
+	}
+
+	var matches []string
+	if strings.Contains(key, "/") {
+		for _, note := range n.notes {
+			if strings.HasSuffix(noteKey(note), "/"+key) {
+				matches = append(matches, note)
+			}
+		}
+	} else {
+		matches = n.byName[key]
+	}
+	if len(matches) > 0 {
+		return n.pick(name, sourcePath, matches)
+	}
+
+	if err := n.loadAliases(); err != nil {
+		return "", err
+	}
+	if matches := n.byAlias[key]; len(matches) > 0 {
+		return n.pick(name, sourcePath, matches)
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoteNotFound, name)
+}
+
+// pick returns the single match, preferring one in the source's folder
+func (n *NoteResolver) pick(name, sourcePath string, matches []string) (string, error) {
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoteNotFound, name)
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	if sourcePath != "" {
+		var local []string
+		for _, m := range matches {
+			if filepath.Dir(m) == filepath.Dir(sourcePath) {
+				local = append(local, m)
+			}
+		}
+		if len(local) == 1 {
+			return local[0], nil
+		}
+	}
+
+	candidates := append([]string(nil), matches...)
+	sortByDepth(candidates)
+	return "", &AmbiguousNoteError{Name: name, Candidates: candidates}
+}
+
+// load scans the vault for notes and indexes them by path and basename
+func (n *NoteResolver) load() error {
+	if n.loaded {
+		return nil
+	}
+
+	n.byPath = make(map[string][]string)
+	n.byName = make(map[string][]string)
+
+	err := filepath.Walk(n.vaultPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+
+		// Skip hidden files and directories
+		if strings.HasPrefix(info.Name(), ".") && p != n.vaultPath {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".md") {
+			return nil
+		}
+		relPath, _ := filepath.Rel(n.vaultPath, p)
+		if !n.policy.CanRead(relPath) || n.paths.Contain(p) != nil {
+			return nil
+		}
+
+		n.notes = append(n.notes, relPath)
+		key := noteKey(relPath)
+		n.byPath[key] = append(n.byPath[key], relPath)
+		n.byName[path.Base(key)] = append(n.byName[path.Base(key)], relPath)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan vault: %w", err)
+	}
+
+	n.loaded = true
+	return nil
+}
+
+// loadAliases indexes the aliases frontmatter property of every note
+func (n *NoteResolver) loadAliases() error {
+	if n.byAlias != nil {
+		return nil
+	}
+	n.byAlias = make(map[string][]string)
+
+	for _, note := range n.notes {
+		content, err := os.ReadFile(filepath.Join(n.vaultPath, note))
+		if err != nil {
+			continue
+		}
+		fm, _, err := ParseFrontmatter(string(content))
+		if err != nil {
+			continue
+		}
+		for _, alias := range Aliases(fm) {
+			key := strings.ToLower(strings.TrimSpace(alias))
+			if key != "" {
+				n.byAlias[key] = append(n.byAlias[key], note)
+			}
+		}
+	}
+	return nil
+}
+
+// Aliases returns a note's aliases from its frontmatter. Obsidian accepts
+// "aliases" or the older "alias", as a list or a single string.
+func Aliases(fm Frontmatter) []string {
+	var aliases []string
+	for _, key := range []string{"aliases", "alias"} {
+		switch v := fm[key].(type) {
+		case string:
+			for _, part := range strings.Split(v, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					aliases = append(aliases, part)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				if item != nil {
+					aliases = append(aliases, fmt.Sprint(item))
+				}
+			}
+		}
+	}
+	return aliases
+}
+
+// linkPathOf strips the subpath (#Heading, #^block) and display text
+// (|alias) from a link target and normalizes its separators
+func linkPathOf(name string) string {
+	if i := strings.Index(name, "|"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.Index(name, "#"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.TrimSpace(filepath.ToSlash(name))
+}
+
+// noteKey is the case-insensitive lookup key for a note path
+func noteKey(p string) string {
+	p = strings.ToLower(filepath.ToSlash(p))
+	return strings.TrimSuffix(p, ".md")
+}
+
+// sortByDepth orders paths shallowest first, then alphabetically
+func sortByDepth(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		di := strings.Count(filepath.ToSlash(paths[i]), "/")
+		dj := strings.Count(filepath.ToSlash(paths[j]), "/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+}
+
+// resolveExisting resolves a name to an existing note: the exact path if
+// there is a note there, otherwise by Obsidian's link resolution rules
+func resolveExisting(vaultPath string, paths *PathResolver, p *policy.Policy, name string) (string, error) {
+	// Exact paths are the common case and don't need a vault scan
+	fullPath, relPath, err := paths.ResolveNote(name)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
+		return relPath, nil
+	}
+
+	resolver := NewNoteResolver(vaultPath)
+	resolver.SetPolicy(p)
+	return resolver.Resolve(name)
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
+)
+
+func TestNoteResolverResolve(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Home.md":                "",
+		"Projects/Plan.md":       "---\naliases: [Roadmap]\n---\n",
+		"Projects/Alpha/Todo.md": "",
+		"Areas/Todo.md":          "",
+		"Areas/Beta/Notes.md":    "---\nalias: Scratch\n---\n",
+		"Archive/Beta/Notes.md":  "",
+		".obsidian/Hidden.md":    "",
+		"Projects/Shared.md":     "---\naliases: [Both]\n---\n",
+		"Areas/Shared.md":        "---\naliases: [Both]\n---\n",
+	})
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		err    error
+	}{
+		{name: "Projects/Plan.md", want: "Projects/Plan.md"},
+		{name: "projects/plan", want: "Projects/Plan.md"},
+		{name: "/Projects/Plan", want: "Projects/Plan.md"},
+		{name: "Plan", want: "Projects/Plan.md"},
+		{name: "Plan#Goals|the plan", want: "Projects/Plan.md"},
+		{name: "Plan#^block", want: "Projects/Plan.md"},
+		{name: "Alpha/Todo", want: "Projects/Alpha/Todo.md"},
+		{name: "Areas/Beta/Notes", want: "Areas/Beta/Notes.md"},
+		{name: "Roadmap", want: "Projects/Plan.md"},
+		{name: "scratch", want: "Areas/Beta/Notes.md"},
+		{name: "Todo", source: "Areas/Index.md", want: "Areas/Todo.md"},
+		{name: "./Todo", source: "Projects/Alpha/Index.md", want: "Projects/Alpha/Todo.md"},
+		{name: "../Plan", source: "Projects/Alpha/Index.md", want: "Projects/Plan.md"},
+		{name: "Shared", source: "Projects/Index.md", want: "Projects/Shared.md"},
+		{name: "Todo", err: &AmbiguousNoteError{}},
+		{name: "Beta/Notes", err: &AmbiguousNoteError{}},
+		{name: "Both", err: &AmbiguousNoteError{}},
+		{name: "Hidden", err: ErrNoteNotFound},
+		{name: "Missing", err: ErrNoteNotFound},
+		{name: "./Plan", source: "Areas/Index.md", err: ErrNoteNotFound},
+		{name: "#Heading", err: ErrNoteNotFound},
+	}
+	resolver := NewNoteResolver(vaultPath)
+	for _, tt := range tests {
+		t.Run(tt.name+" from "+tt.source, func(t *testing.T) {
+			got, err := resolver.ResolveFrom(tt.name, tt.source)
+			switch want := tt.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+			case *AmbiguousNoteError:
+				if !errors.As(err, &want) {
+					t.Fatalf("got %q, %v, want an ambiguous note error", got, err)
+				}
+				return
+			default:
+				if !errors.Is(err, want) {
+					t.Fatalf("got %q, %v, want %v", got, err, want)
+				}
+				return
+			}
+			if filepath.ToSlash(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteResolverPolicy(t *testing.T) {
+	vaultPath := writeVault(t, map[string]string{
+		"Private/Plan.md": "",
+		"Public/Plan.md":  "",
+	})
+	resolver := NewNoteResolver(vaultPath)
+	resolver.SetPolicy(&policy.Policy{ReadDeny: []string{"Private"}})
+
+	got, err := resolver.Resolve("Plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.ToSlash(got) != "Public/Plan.md" {
+		t.Errorf("got %q, want Public/Plan.md", got)
+	}
+	if _, err := resolver.Resolve("Private/Plan"); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("got %v resolving a denied note, want ErrNoteNotFound", err)
+	}
+}
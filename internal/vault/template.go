@@ -0,0 +1,424 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemplateData holds the values available to a template
+type TemplateData struct {
+	// Title is the new note's name, for {{title}}
+	Title string
+	// Folder is the new note's vault-relative folder, for {{folder}}
+	Folder string
+	// Date is the note's date for {{date}}; periodic notes use the start of
+	// their period, other notes the current time
+	Date time.Time
+	// DateFormat and TimeFormat are the default moment.js formats of {{date}} and {{time}}
+	DateFormat string
+	TimeFormat string
+	// Vars are custom variables; they take precedence over the builtins
+	Vars map[string]interface{}
+}
+
+// templateTagRegex matches {{...}} tags
+var templateTagRegex = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// templaterRegex matches the Templater expressions we can evaluate without JavaScript
+var templaterRegex = regexp.MustCompile(`<%[-_]?\s*tp\.(file\.title|file\.folder\(\s*(?:true|false)?\s*\)|date\.now\(\s*(?:"([^"]*)"|'([^']*)')?\s*\))\s*[-_]?%>`)
+
+// templateNode is a node of a parsed template
+type templateNode struct {
+	kind     string // "text", "var", "if", "unless" or "each"
+	text     string // literal text, or the raw tag for variables
+	name     string // variable name or block argument
+	param    string // format after the colon in {{name:param}}
+	children []*templateNode
+	orElse   []*templateNode
+}
+
+// RenderTemplate renders a note template. It supports
+//
+//	{{title}} {{folder}} {{date}} {{time}}   builtins
+//	{{date:YYYY-MM-DD}} {{time:HH:mm}}      moment.js formats
+//	{{name}} {{name.field}}                 custom variables
+//	{{#if name}}...{{else}}...{{/if}}       conditionals ({{#unless}} too)
+//	{{#each list}}{{this}} {{@index}}{{/each}}  loops
+//
+// and the Templater expressions tp.file.title, tp.file.folder() and
+// tp.date.now("FORMAT"). Unknown placeholders are left untouched.
+func RenderTemplate(text string, data TemplateData) (string, error) {
+	if data.Date.IsZero() {
+		data.Date = time.Now()
+	}
+	if data.DateFormat == "" {
+		data.DateFormat = DefaultDailyNoteFormat
+	}
+	if data.TimeFormat == "" {
+		data.TimeFormat = "HH:mm"
+	}
+
+	text = templaterRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := templaterRegex.FindStringSubmatch(match)
+		switch {
+		case parts[1] == "file.title":
+			return data.Title
+		case strings.HasPrefix(parts[1], "file.folder"):
+			return data.Folder
+		default:
+			format := parts[2] + parts[3]
+			if format == "" {
+				format = data.DateFormat
+			}
+			return FormatMoment(time.Now(), format)
+		}
+	})
+
+	nodes, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	r := &templateRenderer{data: data}
+	r.render(&b, nodes, nil)
+	return b.String(), nil
+}
+
+// parseTemplate splits a template into a tree of text, variable and block nodes
+func parseTemplate(text string) ([]*templateNode, error) {
+	root := &templateNode{kind: "root"}
+	stack := []*templateNode{root}
+	inElse := []bool{false}
+
+	add := func(n *templateNode) {
+		top := stack[len(stack)-1]
+		if inElse[len(inElse)-1] {
+			top.orElse = append(top.orElse, n)
+		} else {
+			top.children = append(top.children, n)
+		}
+	}
+
+	last := 0
+	for _, loc := range templateTagRegex.FindAllStringSubmatchIndex(text, -1) {
+		if loc[0] > last {
+			add(&templateNode{kind: "text", text: text[last:loc[0]]})
+		}
+		last = loc[1]
+		raw, tag := text[loc[0]:loc[1]], text[loc[2]:loc[3]]
+
+		switch {
+		case strings.HasPrefix(tag, "#"):
+			kind, arg, _ := strings.Cut(strings.TrimSpace(tag[1:]), " ")
+			if kind != "if" && kind != "unless" && kind != "each" {
+				add(&templateNode{kind: "text", text: raw})
+				continue
+			}
+			block := &templateNode{kind: kind, name: strings.TrimSpace(arg)}
+			if block.name == "" {
+				return nil, fmt.Errorf("template: {{#%s}} needs a variable", kind)
+			}
+			add(block)
+			stack = append(stack, block)
+			inElse = append(inElse, false)
+
+		case tag == "else":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("template: {{else}} outside of a block")
+			}
+			inElse[len(inElse)-1] = true
+
+		case strings.HasPrefix(tag, "/"):
+			kind := strings.TrimSpace(tag[1:])
+			top := stack[len(stack)-1]
+			if len(stack) == 1 || top.kind != kind {
+				return nil, fmt.Errorf("template: unexpected {{/%s}}", kind)
+			}
+			stack = stack[:len(stack)-1]
+			inElse = inElse[:len(inElse)-1]
+
+		default:
+			name, param, _ := strings.Cut(tag, ":")
+			add(&templateNode{kind: "var", text: raw, name: strings.TrimSpace(name), param: strings.TrimSpace(param)})
+		}
+	}
+	if last < len(text) {
+		add(&templateNode{kind: "text", text: text[last:]})
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("template: {{#%s %s}} is not closed", stack[len(stack)-1].kind, stack[len(stack)-1].name)
+	}
+	return root.children, nil
+}
+
+// templateRenderer evaluates parsed templates
+type templateRenderer struct {
+	data TemplateData
+}
+
+// templateScope holds the loop variables of an {{#each}} iteration
+type templateScope struct {
+	item  interface{}
+	index int
+}
+
+func (r *templateRenderer) render(b *strings.Builder, nodes []*templateNode, scope *templateScope) {
+	for _, n := range nodes {
+		switch n.kind {
+		case "text":
+			b.WriteString(n.text)
+
+		case "var":
+			value, ok := r.lookup(n.name, scope)
+			if !ok {
+				b.WriteString(n.text)
+				continue
+			}
+			b.WriteString(r.format(value, n.name, n.param))
+
+		case "if", "unless":
+			value, _ := r.lookup(n.name, scope)
+			if truthy(value) == (n.kind == "if") {
+				r.render(b, n.children, scope)
+			} else {
+				r.render(b, n.orElse, scope)
+			}
+
+		case "each":
+			value, _ := r.lookup(n.name, scope)
+			items := listOf(value)
+			if len(items) == 0 {
+				r.render(b, n.orElse, scope)
+			}
+			for i, item := range items {
+				r.render(b, n.children, &templateScope{item: item, index: i})
+			}
+		}
+	}
+}
+
+// lookup resolves a variable name, including dotted fields such as this.name
+func (r *templateRenderer) lookup(name string, scope *templateScope) (interface{}, bool) {
+	head, rest, _ := strings.Cut(name, ".")
+
+	var value interface{}
+	found := true
+	switch {
+	case head == "this" && scope != nil:
+		value = scope.item
+	case head == "@index" && scope != nil:
+		value = scope.index
+	default:
+		if v, ok := r.data.Vars[head]; ok {
+			value = v
+			break
+		}
+		switch head {
+		case "title":
+			value = r.data.Title
+		case "folder":
+			value = r.data.Folder
+		case "date":
+			value = r.data.Date
+		case "time":
+			value = time.Now()
+		default:
+			found = false
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	for _, field := range strings.Split(rest, ".") {
+		if field == "" {
+			continue
+		}
+		m, ok := asMap(value)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[field]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// format renders a value; times use the tag's moment.js format if given
+func (r *templateRenderer) format(value interface{}, name, param string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if param == "" {
+			param = r.data.DateFormat
+			if name == "time" {
+				param = r.data.TimeFormat
+			}
+		}
+		return FormatMoment(v, param)
+	case Date:
+		if param == "" {
+			return v.String()
+		}
+		return FormatMoment(v.Time, param)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = r.format(item, name, param)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// truthy reports whether a value counts as true in {{#if}}
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "false"
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	if m, ok := asMap(value); ok {
+		return len(m) > 0
+	}
+	return true
+}
+
+// listOf returns the items an {{#each}} iterates over; a single value is a one-item list
+func listOf(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return []interface{}{value}
+}
+
+// asMap returns value as a map, accepting both map types yaml.v3 produces
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case Frontmatter:
+		return v, true
+	}
+	return nil, false
+}
+
+// TemplateSettings are a vault's template settings, from the core Templates
+// plugin (.obsidian/templates.json) and the Templater plugin
+type TemplateSettings struct {
+	// Folder holds the vault's templates; template names are looked up here first
+	Folder string
+	// DateFormat and TimeFormat are the defaults for {{date}} and {{time}}
+	DateFormat string
+	TimeFormat string
+	// FolderTemplates maps folders to the template new notes in them get
+	FolderTemplates []FolderTemplate
+}
+
+// FolderTemplate maps a vault folder to a template note
+type FolderTemplate struct {
+	Folder   string `json:"folder"`
+	Template string `json:"template"`
+}
+
+// LoadTemplateSettings reads the vault's template settings. Missing plugin
+// configuration is not an error.
+func LoadTemplateSettings(vaultPath string) (*TemplateSettings, error) {
+	obsidianDir := filepath.Join(vaultPath, ".obsidian")
+	settings := &TemplateSettings{}
+
+	var core struct {
+		Folder     string `json:"folder"`
+		DateFormat string `json:"dateFormat"`
+		TimeFormat string `json:"timeFormat"`
+	}
+	if _, err := readJSONConfig(filepath.Join(obsidianDir, "templates.json"), &core); err != nil {
+		return nil, err
+	}
+	settings.Folder = core.Folder
+	settings.DateFormat = core.DateFormat
+	settings.TimeFormat = core.TimeFormat
+
+	var templater struct {
+		TemplatesFolder       string           `json:"templates_folder"`
+		EnableFolderTemplates *bool            `json:"enable_folder_templates"`
+		FolderTemplates       []FolderTemplate `json:"folder_templates"`
+	}
+	if _, err := readJSONConfig(filepath.Join(obsidianDir, "plugins", "templater-obsidian", "data.json"), &templater); err != nil {
+		return nil, err
+	}
+	if settings.Folder == "" {
+		settings.Folder = templater.TemplatesFolder
+	}
+	if templater.EnableFolderTemplates == nil || *templater.EnableFolderTemplates {
+		for _, ft := range templater.FolderTemplates {
+			if ft.Template != "" {
+				settings.FolderTemplates = append(settings.FolderTemplates, ft)
+			}
+		}
+	}
+
+	settings.Folder = strings.Trim(strings.TrimSpace(settings.Folder), "/")
+	return settings, nil
+}
+
+// TemplateFor returns the folder template for a note, choosing the most
+// specific folder that contains it, or "" if no folder template applies
+func (s *TemplateSettings) TemplateFor(notePath string) string {
+	dir := path.Dir(filepath.ToSlash(notePath))
+
+	mappings := append([]FolderTemplate(nil), s.FolderTemplates...)
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return len(mappings[i].Folder) > len(mappings[j].Folder)
+	})
+	for _, ft := range mappings {
+		folder := strings.Trim(filepath.ToSlash(ft.Folder), "/")
+		if folder == "" || dir == folder || strings.HasPrefix(dir+"/", folder+"/") {
+			return ft.Template
+		}
+	}
+	return ""
+}
+
+// ParseTemplateVar parses a name=value template variable. Values are typed
+// like properties, and may also be objects such as "person={name: Ann}".
+func ParseTemplateVar(assignment string) (string, interface{}, error) {
+	key, raw, ok := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid variable %q, use name=value", assignment)
+	}
+	if strings.ContainsAny(key, " .{}#/:") {
+		return "", nil, fmt.Errorf("invalid variable name %q", key)
+	}
+	return key, parseYAMLValue(raw), nil
+}
@@ -0,0 +1,209 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/fsutil"
+)
+
+// TrashOption is Obsidian's "Deleted files" setting
+type TrashOption string
+
+const (
+	// TrashSystem moves deleted notes to the operating system's trash
+	TrashSystem TrashOption = "system"
+	// TrashLocal moves deleted notes to the vault's .trash folder
+	TrashLocal TrashOption = "local"
+	// TrashNone deletes notes permanently
+	TrashNone TrashOption = "none"
+)
+
+// TrashFolder is the vault folder Obsidian keeps locally deleted files in
+const TrashFolder = ".trash"
+
+// ParseTrashOption parses a trash setting: system, local (or .trash) or none (or permanent)
+func ParseTrashOption(s string) (TrashOption, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "system":
+		return TrashSystem, nil
+	case "local", ".trash":
+		return TrashLocal, nil
+	case "none", "permanent":
+		return TrashNone, nil
+	}
+	return "", fmt.Errorf("unknown trash option %q (use system, local or none)", s)
+}
+
+// LoadTrashOption reads the vault's "Deleted files" setting from
+// .obsidian/app.json. Obsidian defaults to the system trash.
+func LoadTrashOption(vaultPath string) (TrashOption, error) {
+	var app struct {
+		TrashOption string `json:"trashOption"`
+	}
+	found, err := readJSONConfig(filepath.Join(vaultPath, ".obsidian", "app.json"), &app)
+	if err != nil || !found || app.TrashOption == "" {
+		return TrashSystem, err
+	}
+	return ParseTrashOption(app.TrashOption)
+}
+
+// DeleteResult describes a deleted note
+type DeleteResult struct {
+	Path string `json:"path"`
+	// Trash is where the note went
+	Trash TrashOption `json:"trash"`
+	// Location is the note's path in the trash: vault-relative for the
+	// .trash folder, absolute for the system trash, empty when permanent
+	Location string `json:"location,omitempty"`
+	// Backlinks lists the notes that still link to the deleted note
+	Backlinks []string `json:"backlinks"`
+}
+
+// DeleteNote deletes a note the way the vault's "Deleted files" setting
+// says, unless trash overrides it (""), and reports the notes still linking
+// to it. When the system trash is unavailable the vault's .trash folder is used.
+func (w *Writer) DeleteNote(name string, trash TrashOption) (_ *DeleteResult, err error) {
+	w, done := w.begin("delete")
+	defer done(&err)
+
+	fullPath, relPath, err := w.resolveExisting(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.policy.CheckWrite(relPath); err != nil {
+		return nil, err
+	}
+	if err := w.lock(fullPath); err != nil {
+		return nil, err
+	}
+	if trash == "" {
+		if trash, err = LoadTrashOption(w.vaultPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Backlinks are found across the notes the policy lets us read, before
+	// the note is gone
+	resolver := NewNoteResolver(w.vaultPath)
+	resolver.SetPolicy(w.policy)
+	backlinks, err := resolver.Backlinks(relPath)
+	if err != nil {
+		return nil, err
+	}
+	result := &DeleteResult{Path: relPath, Trash: trash, Backlinks: backlinks}
+
+	switch trash {
+	case TrashNone:
+		if err := w.removeFile(fullPath); err != nil {
+			return nil, fmt.Errorf("failed to delete note: %w", err)
+		}
+		return result, nil
+	case TrashSystem:
+		var location string
+		err := w.moveFile(fullPath, "", func(from, _ string) (err error) {
+			location, err = fsutil.MoveToTrash(from)
+			return err
+		})
+		if err == nil {
+			result.Location = location
+			return result, nil
+		}
+		if !errors.Is(err, fsutil.ErrTrashUnsupported) {
+			return nil, fmt.Errorf("failed to move note to the system trash: %w", err)
+		}
+		result.Trash = TrashLocal
+	}
+
+	// Locally trashed notes keep their folder so they can be restored in place
+	trashPath := fsutil.UniquePath(filepath.Join(w.vaultPath, TrashFolder, relPath))
+	if err := w.mkdirAll(filepath.Dir(trashPath)); err != nil {
+		return nil, fmt.Errorf("failed to create trash folder: %w", err)
+	}
+	if err := w.moveFile(fullPath, trashPath, fsutil.MoveFile); err != nil {
+		return nil, fmt.Errorf("failed to move note to trash: %w", err)
+	}
+	result.Location, _ = filepath.Rel(w.vaultPath, trashPath)
+	return result, nil
+}
+
+// RestoreNote moves a note back from the vault's .trash folder and returns
+// its restored path. name is the note's path in the trash (with or without
+// the .trash/ prefix) or a note name found anywhere in it. The note goes
+// back to its original folder unless dest is given.
+func (w *Writer) RestoreNote(name, dest string) (_ string, err error) {
+	w, done := w.begin("restore")
+	defer done(&err)
+
+	trashRoot := filepath.Join(w.vaultPath, TrashFolder)
+	name = strings.TrimPrefix(filepath.ToSlash(name), TrashFolder+"/")
+
+	trashed, err := findTrashed(trashRoot, name)
+	if err != nil {
+		return "", err
+	}
+	if dest == "" {
+		dest, _ = filepath.Rel(trashRoot, trashed)
+	}
+	fullPath, relPath, err := w.paths.ResolveNote(dest)
+	if err != nil {
+		return "", err
+	}
+	if err := w.policy.CheckWrite(relPath); err != nil {
+		return "", err
+	}
+	if err := w.lock(trashed); err != nil {
+		return "", err
+	}
+	if err := w.lock(fullPath); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(fullPath); err == nil {
+		return "", fmt.Errorf("%w: %s", ErrNoteExists, relPath)
+	}
+
+	if err := w.mkdirAll(filepath.Dir(fullPath)); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := w.moveFile(trashed, fullPath, fsutil.MoveFile); err != nil {
+		return "", fmt.Errorf("failed to restore note: %w", err)
+	}
+	return relPath, nil
+}
+
+// findTrashed finds a note in the trash folder by path, or by name when
+// exactly one trashed note has it
+func findTrashed(trashRoot, name string) (string, error) {
+	if !strings.HasSuffix(strings.ToLower(name), ".md") {
+		name += ".md"
+	}
+	exact := filepath.Join(trashRoot, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(trashRoot, exact); err == nil && !escapes(rel) {
+		if info, err := os.Stat(exact); err == nil && !info.IsDir() {
+			return exact, nil
+		}
+	}
+
+	var matches []string
+	filepath.Walk(trashRoot, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.EqualFold(info.Name(), filepath.Base(name)) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w in %s: %s", ErrNoteNotFound, TrashFolder, name)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, m := range matches {
+		candidates[i], _ = filepath.Rel(filepath.Dir(trashRoot), m)
+	}
+	sortByDepth(candidates)
+	return "", &AmbiguousNoteError{Name: name, Candidates: candidates}
+}
@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// ContentVersion returns the version token of a note's content. Reads return
+// it and writes accept it back as an expected version, so an edit fails
+// instead of overwriting changes made in Obsidian in the meantime. Only the
+// content is hashed: sync tools touch modification times without changing notes.
+func ContentVersion(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ConflictError reports a write whose expected version no longer matches the note
+type ConflictError struct {
+	Path     string
+	Expected string
+	// Current is the note's version now, or empty if it no longer exists
+	Current string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == "" {
+		return fmt.Sprintf("conflict: %s no longer exists (expected version %s)", e.Path, e.Expected)
+	}
+	return fmt.Sprintf("conflict: %s has changed since version %s (current version %s)", e.Path, e.Expected, e.Current)
+}
+
+// Details returns the error as structured data for JSON and MCP clients
+func (e *ConflictError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":             "conflict",
+		"path":             e.Path,
+		"expected_version": e.Expected,
+		"current_version":  e.Current,
+	}
+}
+
+// checkVersion fails with a ConflictError if an expected version was given
+// and the note's content (nil if it doesn't exist) no longer matches it
+func checkVersion(relPath string, content []byte, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	current := ""
+	if content != nil {
+		current = ContentVersion(content)
+	}
+	if current != expected {
+		return &ConflictError{Path: relPath, Expected: expected, Current: current}
+	}
+	return nil
+}
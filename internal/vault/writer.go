@@ -1,95 +1,308 @@
 package vault
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/policy"
 )
 
 // Writer provides write operations for the Obsidian vault
 type Writer struct {
 	vaultPath string
+	paths     *PathResolver
+	policy    *policy.Policy
+
+	journal *Journal
+	source  string     // tags journal entries with the command or tool call
+	op      *Operation // the journal operation in progress, see begin
+	plan    *Operation // collects the changes of a dry run, see DryRun
+	git     *GitCommitter
+	locker  *NoteLocker
 }
 
 // NewWriter creates a new Writer instance
 func NewWriter(vaultPath string) *Writer {
-	return &Writer{vaultPath: vaultPath}
+	return &Writer{vaultPath: vaultPath, paths: NewPathResolver(vaultPath)}
+}
+
+// SetPolicy restricts which notes the writer may modify (nil allows all)
+func (w *Writer) SetPolicy(p *policy.Policy) {
+	w.policy = p
+}
+
+// resolveExisting resolves a note name to the absolute and vault-relative
+// paths of an existing note
+func (w *Writer) resolveExisting(name string) (fullPath, relPath string, err error) {
+	notePath, err := resolveExisting(w.vaultPath, w.paths, w.policy, name)
+	if err != nil {
+		return "", "", err
+	}
+	return w.paths.Resolve(notePath)
 }
 
 // AppendToDailyNote appends a timestamped entry to today's daily note
-// Creates the note if it doesn't exist
-func (w *Writer) AppendToDailyNote(text string) error {
+// Creates the note if it doesn't exist, using the vault's daily note
+// template when one is configured
+func (w *Writer) AppendToDailyNote(text string) (err error) {
+	w, done := w.begin("append")
+	defer done(&err)
+
 	now := time.Now()
-	dateStr := now.Format("2006-01-02")
 	timestamp := now.Format("15:04")
 
-	// Try common daily note locations
-	dailyNotePaths := []string{
-		filepath.Join("Rough Notes", dateStr+".md"),
-		filepath.Join("Daily", dateStr+".md"),
-		dateStr + ".md",
+	// Append the timestamped entry
+	entry := fmt.Sprintf("\n- **%s** %s\n", timestamp, text)
+	_, err = w.appendPeriodic(PeriodDay, now, func(existing string) string {
+		return existing + entry
+	})
+	return err
+}
+
+// AppendToPeriodicNote appends text to the note for the period containing
+// date, creating it from the configured template if needed. It returns the
+// note's vault-relative path.
+func (w *Writer) AppendToPeriodicNote(period Period, date time.Time, text string) (path string, err error) {
+	w, done := w.begin("append")
+	defer done(&err)
+
+	return w.appendPeriodic(period, date, func(existing string) string {
+		return appendBlock(existing, text)
+	})
+}
+
+// CreatePeriodicNote creates the note for the period containing date from
+// the configured template. It returns the note's path and whether it was
+// created; an existing note is left untouched.
+func (w *Writer) CreatePeriodicNote(period Period, date time.Time) (path string, created bool, err error) {
+	w, done := w.begin("create")
+	defer done(&err)
+
+	fullPath, relPath, _, exists, err := w.openPeriodic(period, date)
+	if err != nil || exists {
+		return relPath, false, err
+	}
+	content, err := w.newPeriodicNoteContent(period, relPath, date)
+	if err != nil {
+		return relPath, false, err
+	}
+	if err := w.writeFile(fullPath, []byte(content)); err != nil {
+		return relPath, false, fmt.Errorf("failed to write %s note: %w", settingsKeys[period], err)
+	}
+	return relPath, true, nil
+}
+
+// appendPeriodic rewrites a periodic note with update, creating it first if needed
+func (w *Writer) appendPeriodic(period Period, date time.Time, update func(existing string) string) (string, error) {
+	fullPath, relPath, existingContent, exists, err := w.openPeriodic(period, date)
+	if err != nil {
+		return relPath, err
+	}
+	if !exists {
+		existingContent, err = w.newPeriodicNoteContent(period, relPath, date)
+		if err != nil {
+			return relPath, err
+		}
 	}
 
-	var targetPath string
-	var existingContent string
+	if err := w.writeFile(fullPath, []byte(update(existingContent))); err != nil {
+		return relPath, fmt.Errorf("failed to write %s note: %w", settingsKeys[period], err)
+	}
+	return relPath, nil
+}
+
+// openPeriodic finds the note for a period and checks it may be written. If
+// the note doesn't exist yet, its directory is created at the preferred location.
+func (w *Writer) openPeriodic(period Period, date time.Time) (fullPath, relPath, content string, exists bool, err error) {
+	candidates, _, err := periodicNoteCandidates(w.vaultPath, period, date)
+	if err != nil {
+		return "", "", "", false, err
+	}
 
-	// Find existing daily note or use first path
-	for _, p := range dailyNotePaths {
-		fullPath := filepath.Join(w.vaultPath, p)
-		if content, err := os.ReadFile(fullPath); err == nil {
-			targetPath = fullPath
-			existingContent = string(content)
+	// Find existing note or use first path
+	fullPath, relPath, err = w.paths.Resolve(candidates[0])
+	if err != nil {
+		return "", "", "", false, err
+	}
+	for _, p := range candidates {
+		candidatePath, candidateRel, err := w.paths.Resolve(p)
+		if err != nil {
+			return "", "", "", false, err
+		}
+		if err := w.lock(candidatePath); err != nil {
+			return "", candidateRel, "", false, err
+		}
+		if data, err := w.readFile(candidatePath); err == nil {
+			fullPath, relPath, content, exists = candidatePath, candidateRel, string(data), true
 			break
 		}
 	}
 
-	// If no existing note found, create in Rough Notes
-	if targetPath == "" {
-		targetPath = filepath.Join(w.vaultPath, dailyNotePaths[0])
-		// Create the directory if needed
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return fmt.Errorf("failed to create daily note directory: %w", err)
+	if err := w.policy.CheckWrite(relPath); err != nil {
+		return "", relPath, "", false, err
+	}
+
+	if !exists {
+		if err := w.mkdirAll(filepath.Dir(fullPath)); err != nil {
+			return "", relPath, "", false, fmt.Errorf("failed to create %s note directory: %w", settingsKeys[period], err)
 		}
-		// Initialize with frontmatter
-		existingContent = fmt.Sprintf("---\ndate: %s\ntags:\n  - daily-note\n---\n\n# %s\n\n", dateStr, dateStr)
 	}
+	return fullPath, relPath, content, exists, nil
+}
 
-	// Append the timestamped entry
-	entry := fmt.Sprintf("\n- **%s** %s\n", timestamp, text)
-	newContent := existingContent + entry
+// newPeriodicNoteContent returns the initial content of a new periodic note:
+// the configured template if there is one, an empty note if the period is
+// configured without a template, and our default daily frontmatter for
+// vaults without daily note settings
+func (w *Writer) newPeriodicNoteContent(period Period, relPath string, date time.Time) (string, error) {
+	config, err := LoadPeriodicNoteConfig(w.vaultPath, period)
+	if err != nil {
+		return "", err
+	}
+	if config == nil {
+		dateStr := date.Format("2006-01-02")
+		return fmt.Sprintf("---\ndate: %s\ntags:\n  - daily-note\n---\n\n# %s\n\n", dateStr, dateStr), nil
+	}
+	if config.Template == "" {
+		return "", nil
+	}
 
-	if err := os.WriteFile(targetPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write daily note: %w", err)
+	settings, err := LoadTemplateSettings(w.vaultPath)
+	if err != nil {
+		return "", err
+	}
+	content, err := w.renderTemplateNote(settings, config.Template, TemplateData{
+		Title:      strings.TrimSuffix(filepath.Base(relPath), ".md"),
+		Folder:     folderOf(relPath),
+		Date:       period.Start(date),
+		DateFormat: config.Format,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s note template: %w", settingsKeys[period], err)
 	}
+	return content, nil
+}
+
+// ErrNoteExists is returned when creating a note that already exists
+var ErrNoteExists = errors.New("note already exists")
 
-	return nil
+// ExistsPolicy decides what creating a note does when the note already exists
+type ExistsPolicy string
+
+const (
+	// ExistsFail returns ErrNoteExists (the default)
+	ExistsFail ExistsPolicy = "fail"
+	// ExistsOverwrite replaces the existing note
+	ExistsOverwrite ExistsPolicy = "overwrite"
+	// ExistsSkip leaves the existing note untouched
+	ExistsSkip ExistsPolicy = "skip"
+)
+
+// CreateStatus reports what creating a note did
+type CreateStatus string
+
+const (
+	StatusCreated     CreateStatus = "created"
+	StatusOverwritten CreateStatus = "overwritten"
+	StatusSkipped     CreateStatus = "skipped"
+)
+
+// CreateOptions configures CreateNoteWithOptions
+type CreateOptions struct {
+	// Frontmatter properties, which override the template's
+	Frontmatter Frontmatter
+
+	// Template is a note whose frontmatter and body the new note starts
+	// from, looked up in the templates folder first. Without one, the
+	// vault's folder template for the note's folder is used, if any.
+	Template string
+
+	// Vars are custom template variables
+	Vars map[string]interface{}
+
+	// IfExists decides what happens when the note already exists
+	// Default: ExistsFail
+	IfExists ExistsPolicy
 }
 
 // CreateNote creates a new note with optional frontmatter
 func (w *Writer) CreateNote(path, content string, frontmatter Frontmatter) error {
-	// Ensure .md extension
-	if !strings.HasSuffix(path, ".md") {
-		path = path + ".md"
-	}
+	_, _, err := w.CreateNoteWithOptions(path, content, CreateOptions{Frontmatter: frontmatter})
+	return err
+}
+
+// CreateNoteWithOptions creates a note from content, frontmatter and an
+// optional template. It returns the note's vault-relative path and what was done.
+func (w *Writer) CreateNoteWithOptions(path, content string, opts CreateOptions) (_ string, _ CreateStatus, err error) {
+	w, done := w.begin("create")
+	defer done(&err)
 
-	fullPath := filepath.Join(w.vaultPath, path)
+	fullPath, path, err := w.paths.ResolveNote(path)
+	if err != nil {
+		return "", "", err
+	}
+	if err := w.policy.CheckWrite(path); err != nil {
+		return path, "", err
+	}
+	if err := w.lock(fullPath); err != nil {
+		return path, "", err
+	}
 
 	// Check if file already exists
+	status := StatusCreated
 	if _, err := os.Stat(fullPath); err == nil {
-		return fmt.Errorf("note already exists: %s", path)
+		switch opts.IfExists {
+		case ExistsSkip:
+			return path, StatusSkipped, nil
+		case ExistsOverwrite:
+			status = StatusOverwritten
+		default:
+			return path, "", fmt.Errorf("%w: %s", ErrNoteExists, path)
+		}
+	}
+
+	settings, err := LoadTemplateSettings(w.vaultPath)
+	if err != nil {
+		return path, "", err
+	}
+	template := opts.Template
+	if template == "" {
+		template = settings.TemplateFor(path)
+	}
+
+	frontmatter := make(Frontmatter)
+	if template != "" {
+		templateFm, templateBody, err := w.loadTemplate(settings, template, TemplateData{
+			Title:  strings.TrimSuffix(filepath.Base(path), ".md"),
+			Folder: folderOf(path),
+			Vars:   opts.Vars,
+		})
+		if err != nil {
+			return path, "", err
+		}
+		frontmatter = MergeFrontmatter(frontmatter, templateFm)
+		if content == "" {
+			content = templateBody
+		} else {
+			content = appendBlock(templateBody, content)
+		}
+	}
+	frontmatter = MergeFrontmatter(frontmatter, opts.Frontmatter)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
 	}
 
 	// Create directory structure if needed
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := w.mkdirAll(filepath.Dir(fullPath)); err != nil {
+		return path, "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	// Add creation timestamp to frontmatter
-	if frontmatter == nil {
-		frontmatter = make(Frontmatter)
-	}
 	if _, exists := frontmatter["created"]; !exists {
 		frontmatter["created"] = time.Now().Format(time.RFC3339)
 	}
@@ -97,130 +310,294 @@ func (w *Writer) CreateNote(path, content string, frontmatter Frontmatter) error
 	// Combine frontmatter and content
 	fileContent, err := CombineFrontmatterAndContent(frontmatter, content)
 	if err != nil {
-		return fmt.Errorf("failed to create note content: %w", err)
+		return path, "", fmt.Errorf("failed to create note content: %w", err)
 	}
 
-	if err := os.WriteFile(fullPath, []byte(fileContent), 0644); err != nil {
-		return fmt.Errorf("failed to write note: %w", err)
+	if err := w.writeFile(fullPath, []byte(fileContent)); err != nil {
+		return path, "", fmt.Errorf("failed to write note: %w", err)
 	}
 
-	return nil
+	return path, status, nil
 }
 
-// UpdateFrontmatter updates a specific key in a note's frontmatter
-func (w *Writer) UpdateFrontmatter(path, key string, value interface{}) error {
-	// Ensure .md extension
-	if !strings.HasSuffix(path, ".md") {
-		path = path + ".md"
+// loadTemplate renders a template note and splits it into frontmatter and body
+func (w *Writer) loadTemplate(settings *TemplateSettings, name string, data TemplateData) (Frontmatter, string, error) {
+	rendered, err := w.renderTemplateNote(settings, name, data)
+	if err != nil {
+		return nil, "", err
 	}
-
-	fullPath := filepath.Join(w.vaultPath, path)
-
-	// Read existing content
-	content, err := os.ReadFile(fullPath)
+	fm, body, err := ParseFrontmatter(rendered)
 	if err != nil {
-		return fmt.Errorf("failed to read note: %w", err)
+		return nil, "", fmt.Errorf("failed to parse template: %w", err)
 	}
+	return fm, strings.TrimPrefix(body, "\n"), nil
+}
 
-	// Parse existing frontmatter
-	fm, body, err := ParseFrontmatter(string(content))
+// renderTemplateNote reads a template note, looking in the templates folder
+// first, and renders it
+func (w *Writer) renderTemplateNote(settings *TemplateSettings, name string, data TemplateData) (string, error) {
+	templatePath, templateRel, err := w.findTemplate(settings, name)
 	if err != nil {
-		return fmt.Errorf("failed to parse frontmatter: %w", err)
+		return "", fmt.Errorf("failed to find template: %w", err)
 	}
-
-	// Initialize frontmatter if needed
-	if fm == nil {
-		fm = make(Frontmatter)
+	if err := w.policy.CheckRead(templateRel); err != nil {
+		return "", err
 	}
-
-	// Update the key
-	fm[key] = value
-
-	// Rebuild the file
-	newContent, err := CombineFrontmatterAndContent(fm, body)
+	source, err := os.ReadFile(templatePath)
 	if err != nil {
-		return fmt.Errorf("failed to rebuild note: %w", err)
+		return "", fmt.Errorf("failed to read template: %w", err)
 	}
 
-	if err := os.WriteFile(fullPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write note: %w", err)
+	if data.DateFormat == "" {
+		data.DateFormat = settings.DateFormat
+	}
+	if data.TimeFormat == "" {
+		data.TimeFormat = settings.TimeFormat
 	}
+	rendered, err := RenderTemplate(string(source), data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", templateRel, err)
+	}
+	return rendered, nil
+}
 
-	return nil
+// findTemplate resolves a template name, preferring the templates folder
+func (w *Writer) findTemplate(settings *TemplateSettings, name string) (fullPath, relPath string, err error) {
+	if settings.Folder != "" {
+		if fullPath, relPath, err := w.paths.ResolveNote(path.Join(settings.Folder, filepath.ToSlash(name))); err == nil {
+			if _, err := os.Stat(fullPath); err == nil {
+				return fullPath, relPath, nil
+			}
+		}
+	}
+	return w.resolveExisting(name)
 }
 
-// LinkNotes appends a wikilink from source to target
-func (w *Writer) LinkNotes(source, target string) error {
-	// Ensure .md extension for source
-	if !strings.HasSuffix(source, ".md") {
-		source = source + ".md"
+// folderOf returns the slash-separated folder of a vault-relative path ("" for the root)
+func folderOf(relPath string) string {
+	dir := filepath.ToSlash(filepath.Dir(relPath))
+	if dir == "." {
+		return ""
 	}
+	return dir
+}
+
+// UpdateFrontmatter updates a specific key in a note's frontmatter and
+// returns the note's new version. If expectedVersion is set and the note has
+// changed since, nothing is written and a ConflictError is returned.
+func (w *Writer) UpdateFrontmatter(path, key string, value interface{}, expectedVersion string) (version string, err error) {
+	w, done := w.begin("frontmatter")
+	defer done(&err)
+
+	return w.rewriteNote(path, expectedVersion, func(content string) (string, error) {
+		fm, err := EditFrontmatter(content)
+		if err != nil {
+			return "", err
+		}
+		if err := fm.Set(key, value); err != nil {
+			return "", err
+		}
+		return fm.String(), nil
+	})
+}
+
+// DefaultRelatedHeading is the section LinkNotes lists links under
+const DefaultRelatedHeading = "## Related"
+
+// LinkOptions controls how LinkNotes adds a link
+type LinkOptions struct {
+	// Heading is the section links are listed under, created if missing.
+	// Default: DefaultRelatedHeading
+	Heading string
+
+	// Alias is the link's display text, as in [[Target|Alias]]
+	Alias string
+
+	// Property adds the link to this frontmatter list property (e.g.
+	// "related") instead of the Related section
+	Property string
+
+	// Bidirectional also links the target back to the source
+	Bidirectional bool
+
+	// ExpectedVersion makes the link fail with a ConflictError if the
+	// source note has changed since this version
+	ExpectedVersion string
+}
 
-	sourcePath := filepath.Join(w.vaultPath, source)
+// LinkResult describes the links LinkNotes added
+type LinkResult struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Link is the link as written, e.g. [[Projects/Plan|the plan]]
+	Link string `json:"link"`
+	// Added is false if the source already linked to the target
+	Added bool `json:"added"`
+	// Version is the source note's new version
+	Version string `json:"version"`
+	// LinkedBack and TargetVersion report the link back from the target
+	LinkedBack    bool   `json:"linked_back,omitempty"`
+	TargetVersion string `json:"target_version,omitempty"`
+}
 
-	// Read existing content
-	content, err := os.ReadFile(sourcePath)
+// LinkNotes adds a [[wikilink]] from source to target as a bullet in the
+// source's Related section, or in a frontmatter property. Nothing is added
+// if the source already links to the target. The link uses the shortest
+// path that identifies the target, so same-named notes in different folders
+// stay distinct. Targets that don't exist yet are linked by name, as
+// Obsidian does.
+func (w *Writer) LinkNotes(source, target string, opts LinkOptions) (_ *LinkResult, err error) {
+	w, done := w.begin("link")
+	defer done(&err)
+
+	_, sourcePath, err := w.resolveExisting(source)
 	if err != nil {
-		return fmt.Errorf("failed to read source note: %w", err)
+		return nil, err
+	}
+	if err := w.policy.CheckWrite(sourcePath); err != nil {
+		return nil, err
 	}
 
-	// Clean target for wikilink (remove .md extension and path)
-	linkTarget := strings.TrimSuffix(filepath.Base(target), ".md")
-	wikilink := fmt.Sprintf("[[%s]]", linkTarget)
+	resolver := NewNoteResolver(w.vaultPath)
+	resolver.SetPolicy(w.policy)
+	notes, err := resolver.Notes()
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if link already exists
-	if strings.Contains(string(content), wikilink) {
-		return nil // Link already exists, no-op
+	result := &LinkResult{Source: sourcePath}
+	link := strings.TrimSuffix(strings.TrimSpace(filepath.ToSlash(target)), ".md")
+	if targetPath, err := resolver.Resolve(target); err == nil {
+		result.Target = targetPath
+		link = shortestLinkPath(targetPath, notes)
+	} else if !errors.Is(err, ErrNoteNotFound) {
+		return nil, err
+	} else if opts.Bidirectional {
+		return nil, fmt.Errorf("cannot link back from %s: %w", target, err)
+	}
+	if opts.Bidirectional {
+		if err := w.policy.CheckWrite(result.Target); err != nil {
+			return nil, err
+		}
 	}
 
-	// Append the link
-	newContent := string(content)
-	if !strings.HasSuffix(newContent, "\n") {
-		newContent += "\n"
+	result.Link = "[[" + link + "]]"
+	if opts.Alias != "" {
+		result.Link = "[[" + link + "|" + opts.Alias + "]]"
+	}
+	result.Version, result.Added, err = w.addLink(resolver, sourcePath, result.Target, link, result.Link, opts, opts.ExpectedVersion)
+	if err != nil {
+		return nil, err
 	}
-	newContent += fmt.Sprintf("\n## Related\n- %s\n", wikilink)
 
-	if err := os.WriteFile(sourcePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write source note: %w", err)
+	if opts.Bidirectional {
+		backLink := shortestLinkPath(sourcePath, notes)
+		result.TargetVersion, result.LinkedBack, err = w.addLink(resolver, result.Target, sourcePath, backLink, "[["+backLink+"]]", opts, "")
+		if err != nil {
+			return result, fmt.Errorf("linked %s to %s but failed to link back: %w", sourcePath, result.Target, err)
+		}
 	}
+	return result, nil
+}
 
-	return nil
+// addLink writes wikilink into a note unless it already links to target
+// (or, for targets that don't exist, to name), reporting whether it was added
+func (w *Writer) addLink(resolver *NoteResolver, notePath, target, name, wikilink string, opts LinkOptions, expectedVersion string) (string, bool, error) {
+	added := false
+	version, err := w.rewriteNote(notePath, expectedVersion, func(content string) (string, error) {
+		if linksTo(resolver, content, notePath, target, name) {
+			return content, nil
+		}
+		added = true
+		if opts.Property != "" {
+			return addPropertyLink(content, opts.Property, wikilink)
+		}
+		heading := opts.Heading
+		if heading == "" {
+			heading = DefaultRelatedHeading
+		}
+		return insertUnderHeading(content, heading, "- "+wikilink), nil
+	})
+	return version, added, err
 }
 
-// AppendToNote appends text to a note, creating it if it doesn't exist
-func (w *Writer) AppendToNote(path, text string) error {
-	// Ensure .md extension
-	if !strings.HasSuffix(path, ".md") {
-		path = path + ".md"
+// addPropertyLink appends a link to a frontmatter list property, turning a
+// single value into a list
+func addPropertyLink(content, property, wikilink string) (string, error) {
+	fm, err := EditFrontmatter(content)
+	if err != nil {
+		return "", err
+	}
+	current, _ := fm.Get(property)
+	switch v := current.(type) {
+	case nil:
+		current = []interface{}{wikilink}
+	case []interface{}:
+		current = append(v, wikilink)
+	default:
+		current = []interface{}{v, wikilink}
+	}
+	if err := fm.Set(property, current); err != nil {
+		return "", err
+	}
+	return fm.String(), nil
+}
+
+// AppendToNote appends text to a note, creating it if it doesn't exist, and
+// returns the note's new version. If expectedVersion is set and the note has
+// changed since, nothing is written and a ConflictError is returned.
+func (w *Writer) AppendToNote(name, text, expectedVersion string) (version string, err error) {
+	w, done := w.begin("append")
+	defer done(&err)
+
+	fullPath, path, err := w.resolveExisting(name)
+	if errors.Is(err, ErrNoteNotFound) {
+		// New notes are created at the literal path
+		fullPath, path, err = w.paths.ResolveNote(name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := w.policy.CheckWrite(path); err != nil {
+		return "", err
+	}
+	if err := w.lock(fullPath); err != nil {
+		return "", err
 	}
 
-	fullPath := filepath.Join(w.vaultPath, path)
+	// Read existing content if file exists, and check its version before
+	// touching the vault
+	content, err := w.readFile(fullPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read note: %w", err)
+	}
+	if err := checkVersion(path, content, expectedVersion); err != nil {
+		return "", err
+	}
 
 	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := w.mkdirAll(filepath.Dir(fullPath)); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Read existing content if file exists
-	existingContent := ""
-	if content, err := os.ReadFile(fullPath); err == nil {
-		existingContent = string(content)
+	newContent := appendBlock(string(content), text)
+
+	if err := w.writeFile(fullPath, []byte(newContent)); err != nil {
+		return "", fmt.Errorf("failed to write note: %w", err)
 	}
 
+	return ContentVersion([]byte(newContent)), nil
+}
+
+// appendBlock appends text to content as its own paragraph
+func appendBlock(content, text string) string {
 	// Ensure newline separation if file is not empty
-	if existingContent != "" && !strings.HasSuffix(existingContent, "\n") {
-		existingContent += "\n"
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
 	}
 	// Add an extra newline for separation if appending to existing content
-	if existingContent != "" {
-		existingContent += "\n"
+	if content != "" {
+		content += "\n"
 	}
-
-	newContent := existingContent + text + "\n"
-
-	if err := os.WriteFile(fullPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write note: %w", err)
-	}
-
-	return nil
+	return content + text + "\n"
 }
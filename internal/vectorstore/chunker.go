@@ -3,6 +3,8 @@ package vectorstore
 import (
 	"strings"
 	"unicode"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/vault"
 )
 
 // ChunkConfig holds configuration for text chunking
@@ -24,7 +26,8 @@ type Chunk struct {
 	ParentID    string // ID of the parent document
 }
 
-// ChunkText splits text into chunks based on the configuration
+// ChunkText splits text into chunks based on the configuration. Text is
+// parsed as a note so chunks break between sections and blocks.
 func ChunkText(text string, parentID string, config ChunkConfig) []Chunk {
 	// Set defaults
 	if config.MaxChunkSize == 0 {
@@ -51,6 +54,7 @@ func ChunkText(text string, parentID string, config ChunkConfig) []Chunk {
 
 	var chunks []Chunk
 	start := 0
+	doc := vault.ParseDocument(text)
 
 	for start < len(text) {
 		// Calculate end position
@@ -61,7 +65,7 @@ func ChunkText(text string, parentID string, config ChunkConfig) []Chunk {
 
 		// Try to break at a natural boundary (paragraph, sentence, or word)
 		if end < len(text) {
-			end = findBreakPoint(text, start, end)
+			end = findBreakPoint(text, doc, start, end)
 		}
 
 		// Extract chunk
@@ -98,13 +102,34 @@ func ChunkText(text string, parentID string, config ChunkConfig) []Chunk {
 }
 
 // findBreakPoint finds a natural break point near the target position
-func findBreakPoint(text string, start, target int) int {
+func findBreakPoint(text string, doc *vault.Document, start, target int) int {
+	// Keep sections together when one starts in the second half of the chunk
+	half := start + (target-start)/2
+	for i := len(doc.Headings) - 1; i >= 0; i-- {
+		if h := doc.Headings[i]; h.Start > half && h.Start <= target {
+			return h.Start
+		}
+	}
+
 	// Look back from target for a natural break
 	searchStart := target - 200 // Look back up to 200 chars
 	if searchStart < start {
 		searchStart = start
 	}
 
+	// Try to break before a block (list item, code block, callout), or
+	// before one the target would split if it starts in the second half
+	for i := len(doc.Blocks) - 1; i >= 0; i-- {
+		b := doc.Blocks[i]
+		if b.Start > target {
+			continue
+		}
+		if b.Start > searchStart || (b.End > target && b.Start > half) {
+			return b.Start
+		}
+		break
+	}
+
 	// Try to find paragraph break (double newline)
 	if idx := strings.LastIndex(text[searchStart:target], "\n\n"); idx != -1 {
 		return searchStart + idx + 2
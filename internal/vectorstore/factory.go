@@ -9,6 +9,7 @@ import (
 type VectorStore interface {
 	IndexDocument(id, title, content string) error
 	RemoveDocument(id string) error
+	RenameDocument(oldID, newID string) error
 	SemanticSearch(query string, limit int) ([]SearchResult, error)
 	DocumentCount() int
 	HasDocument(id string) bool
@@ -173,13 +173,8 @@ func (s *QdrantStore) IndexDocument(id, title, content string) error {
 			return fmt.Errorf("failed to generate embedding for chunk %d: %w", chunk.Index, err)
 		}
 
-		// Create unique ID for this chunk
-		chunkID := fmt.Sprintf("%s#chunk%d", id, chunk.Index)
-		idHash := sha256.Sum256([]byte(chunkID))
-		idUUID := hex.EncodeToString(idHash[:16])
-
 		point := &qdrant.PointStruct{
-			Id:      qdrant.NewID(idUUID),
+			Id:      chunkPointID(id, chunk.Index),
 			Vectors: qdrant.NewVectors(embedding...),
 			Payload: qdrant.NewValueMap(map[string]any{
 				"id":           id,
@@ -217,22 +212,7 @@ func (s *QdrantStore) RemoveDocument(id string) error {
 		CollectionName: s.collectionName,
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Filter{
-				Filter: &qdrant.Filter{
-					Must: []*qdrant.Condition{
-						{
-							ConditionOneOf: &qdrant.Condition_Field{
-								Field: &qdrant.FieldCondition{
-									Key: "id",
-									Match: &qdrant.Match{
-										MatchValue: &qdrant.Match_Keyword{
-											Keyword: id,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
+				Filter: documentFilter(id),
 			},
 		},
 	})
@@ -244,6 +224,79 @@ func (s *QdrantStore) RemoveDocument(id string) error {
 	return nil
 }
 
+// RenameDocument moves a document's chunks to a new ID, keeping their
+// embeddings so a renamed note doesn't need to be re-embedded
+func (s *QdrantStore) RenameDocument(oldID, newID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	limit := uint32(10000)
+	points, err := s.client.Scroll(ctx, &qdrant.ScrollPoints{
+		CollectionName: s.collectionName,
+		Filter:         documentFilter(oldID),
+		Limit:          &limit,
+		WithPayload:    qdrant.NewWithPayload(true),
+		WithVectors:    qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	renamed := make([]*qdrant.PointStruct, 0, len(points))
+	for _, point := range points {
+		payload := point.GetPayload()
+		payload["id"] = qdrant.NewValueString(newID)
+		vector := point.GetVectors().GetVector()
+		embedding := vector.GetDense().GetData()
+		if len(embedding) == 0 {
+			embedding = vector.GetData()
+		}
+		renamed = append(renamed, &qdrant.PointStruct{
+			Id:      chunkPointID(newID, int(payload["chunk_index"].GetIntegerValue())),
+			Vectors: qdrant.NewVectors(embedding...),
+			Payload: payload,
+		})
+	}
+
+	// Write the new points before deleting the old ones so a failure loses nothing
+	if _, err := s.client.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: s.collectionName,
+		Points:         renamed,
+	}); err != nil {
+		return fmt.Errorf("failed to upsert points: %w", err)
+	}
+	return s.RemoveDocument(oldID)
+}
+
+// documentFilter matches every chunk of a document
+func documentFilter(id string) *qdrant.Filter {
+	return &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			{
+				ConditionOneOf: &qdrant.Condition_Field{
+					Field: &qdrant.FieldCondition{
+						Key: "id",
+						Match: &qdrant.Match{
+							MatchValue: &qdrant.Match_Keyword{
+								Keyword: id,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// chunkPointID derives the point ID of a document chunk
+func chunkPointID(id string, index int) *qdrant.PointId {
+	idHash := sha256.Sum256([]byte(fmt.Sprintf("%s#chunk%d", id, index)))
+	return qdrant.NewID(hex.EncodeToString(idHash[:16]))
+}
+
 // SemanticSearch finds documents similar to the query
 // Automatically aggregates chunks from the same document
 func (s *QdrantStore) SemanticSearch(query string, limit int) ([]SearchResult, error) {
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/chadmowery/obsidian-agent-tools/internal/fsutil"
 )
 
 // Document represents an indexed document in the vector store
@@ -82,7 +84,7 @@ func (s *Store) save() error {
 		return fmt.Errorf("failed to marshal store: %w", err)
 	}
 
-	if err := os.WriteFile(s.path, data, 0644); err != nil {
+	if err := fsutil.WriteFile(s.path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write store: %w", err)
 	}
 
@@ -130,6 +132,23 @@ func (s *Store) RemoveDocument(id string) error {
 	return s.save()
 }
 
+// RenameDocument moves a document to a new ID, keeping its embedding
+func (s *Store) RenameDocument(oldID, newID string) error {
+	s.mu.Lock()
+	doc, ok := s.documents[oldID]
+	if ok {
+		delete(s.documents, oldID)
+		doc.ID = newID
+		s.documents[newID] = doc
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return s.save()
+}
+
 // SearchResult represents a search result with similarity score
 type SearchResult struct {
 	Document   Document
@@ -165,6 +165,11 @@ func (w *Watcher) debounceEvent(path string, op FileOp) {
 	// Cancel existing timer if present
 	if existing, ok := w.debouncer.events[path]; ok {
 		existing.timer.Stop()
+
+		// A write right after a create is still a new file to consumers
+		if existing.op == OpCreate && op == OpModify {
+			op = OpCreate
+		}
 	}
 
 	// Create new debounced event